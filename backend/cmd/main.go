@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"gophermatebackend/internal/api"
@@ -11,6 +12,8 @@ import (
 )
 
 const defaultCleanupInterval = 30 * 60 * time.Second
+const flagCheckInterval = 1 * time.Second
+const matchmakingCleanupInterval = 30 * time.Second
 
 func main() {
 	// Load environment variables or default values
@@ -43,18 +46,63 @@ func main() {
 		}
 	}()
 
+	// Start periodic matchmaking queue cleanup
+	go func() {
+		for {
+			cache.CleanExpiredMatches()
+			time.Sleep(matchmakingCleanupInterval)
+		}
+	}()
+
+	// Start flag-fall sweeper for timed games
+	go func() {
+		for {
+			api.FinishTimedOutGames()
+			time.Sleep(flagCheckInterval)
+		}
+	}()
+
 	// Set up routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/register", api.RegisterHandler)
 	mux.HandleFunc("/api/login", api.LoginHandler)
 	mux.HandleFunc("/api/logout", api.LogoutHandler)
+	mux.HandleFunc("/api/logout/all", api.LogoutAllHandler)
+	mux.HandleFunc("/api/refresh", api.RefreshHandler)
+	mux.HandleFunc("/api/sessions", api.SessionsHandler)
+	mux.HandleFunc("/api/sessions/", api.SessionsHandler)
 	mux.HandleFunc("/api/me", api.MeHandler)
+	mux.HandleFunc("/api/users/", api.UsersHandler)
+	mux.HandleFunc("/api/matchmaking", api.MatchmakingHandler)
+	mux.HandleFunc("/api/challenges", api.ChallengesHandler)
+	mux.HandleFunc("/api/challenges/", api.ChallengesHandler)
+	mux.HandleFunc("/api/invites/", api.InviteJoinHandler)
 	gamesHandler := func(w http.ResponseWriter, r *http.Request) {
 		// Handle /api/games/{id}/board for board state polling
 		if r.Method == http.MethodGet && len(r.URL.Path) > len("/api/games/") && r.URL.Path[len(r.URL.Path)-6:] == "/board" {
 			api.BoardStateHandler(w, r)
 			return
 		}
+		// Handle /api/games/{id}/state for the full board snapshot
+		if r.Method == http.MethodGet && len(r.URL.Path) > len("/api/games/") && r.URL.Path[len(r.URL.Path)-6:] == "/state" {
+			api.GameStateHandler(w, r)
+			return
+		}
+		// Handle /api/games/{id}/pgn for PGN export
+		if r.Method == http.MethodGet && len(r.URL.Path) > len("/api/games/") && r.URL.Path[len(r.URL.Path)-4:] == "/pgn" {
+			api.PGNHandler(w, r)
+			return
+		}
+		// Handle /api/games/{id}/ws for pushed game events
+		if r.Method == http.MethodGet && len(r.URL.Path) > len("/api/games/") && r.URL.Path[len(r.URL.Path)-3:] == "/ws" {
+			api.GameWebSocketHandler(w, r)
+			return
+		}
+		// Handle /api/games/{id}/events for the Server-Sent Events stream
+		if r.Method == http.MethodGet && len(r.URL.Path) > len("/api/games/") && r.URL.Path[len(r.URL.Path)-7:] == "/events" {
+			api.GameEventsHandler(w, r)
+			return
+		}
 		// Handle /api/games/{id}/join for joining a game
 		if r.Method == http.MethodPost && len(r.URL.Path) > len("/api/games/") && r.URL.Path[len(r.URL.Path)-5:] == "/join" {
 			api.JoinGameHandler(w, r)
@@ -75,6 +123,26 @@ func main() {
 			api.DeclineDrawHandler(w, r)
 			return
 		}
+		// Handle /api/games/{id}/offer-rematch
+		if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/games/") && strings.HasSuffix(r.URL.Path, "/offer-rematch") {
+			api.OfferRematchHandler(w, r)
+			return
+		}
+		// Handle /api/games/{id}/accept-rematch
+		if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/games/") && strings.HasSuffix(r.URL.Path, "/accept-rematch") {
+			api.AcceptRematchHandler(w, r)
+			return
+		}
+		// Handle /api/games/{id}/decline-rematch
+		if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/games/") && strings.HasSuffix(r.URL.Path, "/decline-rematch") {
+			api.DeclineRematchHandler(w, r)
+			return
+		}
+		// Handle /api/games/import for PGN upload
+		if r.Method == http.MethodPost && r.URL.Path == "/api/games/import" {
+			api.ImportPGNHandler(w, r)
+			return
+		}
 		// Handle /api/games/move
 		if r.Method == http.MethodPost && r.URL.Path == "/api/games/move" {
 			api.MoveHandler(w, r)
@@ -95,9 +163,9 @@ func main() {
 	mux.HandleFunc("/api/games", gamesHandler)
 	mux.HandleFunc("/api/games/", gamesHandler)
 
-	// Wrap the mux with the Logging and CORS middleware
+	// Wrap the mux with the Logging, CORS and Auth middleware
 	// handler := api.CORSMiddleware(mux)
-	handler := api.LoggingMiddleware(api.CORSMiddleware(mux))
+	handler := api.LoggingMiddleware(api.CORSMiddleware(api.AuthMiddleware(mux)))
 
 	// Start HTTP server
 	log.Printf("Server is running on port %s", port)
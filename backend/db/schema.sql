@@ -13,6 +13,21 @@ CREATE TABLE games (
     player_white_id INTEGER REFERENCES users(id),
     player_black_id INTEGER REFERENCES users(id),
     winner TEXT, -- 'white', 'black', 'draw', or NULL
+    result_reason TEXT, -- 'checkmate', 'stalemate', 'resignation', 'agreement', or NULL
+    initial_fen TEXT, -- starting position for games created from a custom FEN, NULL for the standard start
+    white_name TEXT, -- player names of games imported from PGN, which are not linked to accounts
+    black_name TEXT,
+    imported_by INTEGER REFERENCES users(id), -- set for games imported from PGN
+    time_control TEXT, -- '5+3' (minutes + increment seconds) or '3d' (days per move), NULL for untimed games
+    rating_category TEXT, -- rating pool of the time control: 'bullet', 'blitz', 'rapid', 'classical' or 'correspondence', NULL for untimed games
+    rated BOOLEAN NOT NULL DEFAULT FALSE, -- rated games update the players' ratings when they finish, casual games do not
+    white_time_ms BIGINT, -- remaining clock time at the start of the current turn
+    black_time_ms BIGINT,
+    turn_started_at TIMESTAMP, -- UTC time the side to move started its turn, NULL until the first move
+    turn_deadline TIMESTAMP, -- UTC time the side to move runs out of time, used by the flag-fall sweeper
+    invite_code TEXT UNIQUE, -- set for private games, which are only reachable through the invite code
+    rematch_of UUID UNIQUE REFERENCES games(id), -- finished game this game is a rematch of
+    rematch_offered_by TEXT, -- 'white' or 'black' while a rematch offer on this finished game is pending
     created_at TIMESTAMP DEFAULT NOW(),
     finished_at TIMESTAMP
 );
@@ -23,14 +38,68 @@ CREATE TABLE moves (
     game_id UUID REFERENCES games(id) ON DELETE CASCADE,
     player_id INTEGER REFERENCES users(id),
     move_number INTEGER,
-    notation TEXT,
+    notation TEXT, -- SAN (e.g. 'Nf3'); rows written before SAN support use 'white-pawn e2->e4'
+    uci TEXT, -- long algebraic notation (e.g. 'g1f3'), NULL for legacy rows
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Challenges table: a direct game invitation to a named user
+CREATE TABLE challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    challenger_id INTEGER REFERENCES users(id),
+    recipient_id INTEGER REFERENCES users(id),
+    time_control TEXT, -- same format as games.time_control, NULL for untimed games
+    color TEXT NOT NULL DEFAULT 'random', -- challenger's color: 'white', 'black' or 'random'
+    rated BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'accepted', 'declined' or 'cancelled'
+    game_id UUID REFERENCES games(id), -- game created when the challenge is accepted
+    created_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    answered_at TIMESTAMP -- when the challenge was accepted, declined or cancelled
+);
+
+-- Ratings table: one Glicko-2 rating per user and rating pool, created with the user's first rated game in the pool
+CREATE TABLE ratings (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT NOT NULL, -- same values as games.rating_category
+    rating DOUBLE PRECISION NOT NULL,
+    deviation DOUBLE PRECISION NOT NULL, -- the rating is provisional while the deviation is above 110
+    volatility DOUBLE PRECISION NOT NULL,
+    games_played INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, category)
+);
+
+-- Rating history table: the rating change of each player after each rated game
+CREATE TABLE rating_history (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    game_id UUID REFERENCES games(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    rating_before DOUBLE PRECISION NOT NULL,
+    rating_after DOUBLE PRECISION NOT NULL,
+    deviation_after DOUBLE PRECISION NOT NULL,
     created_at TIMESTAMP DEFAULT NOW()
 );
 
 -- Sessions table
 CREATE TABLE sessions (
     token UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    id SERIAL UNIQUE, -- identifies the session when listing or revoking it, so the token is never exposed
     user_id INTEGER REFERENCES users(id),
+    user_agent TEXT, -- client that logged in
+    ip_address TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    last_seen_at TIMESTAMP DEFAULT NOW(), -- last authenticated request, updated at most once a minute
+    expires_at TIMESTAMP, -- UTC, slides forward on every use, the token is rejected once it has passed
+    revoked_at TIMESTAMP -- set on logout, the token no longer authenticates
+);
+
+-- Refresh tokens table: every token rotated from one login belongs to the same session
+CREATE TABLE refresh_tokens (
+    token UUID PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
     created_at TIMESTAMP DEFAULT NOW(),
-    expires_at TIMESTAMP
+    expires_at TIMESTAMP NOT NULL, -- UTC
+    used_at TIMESTAMP -- set when exchanged; presenting the token again revokes the session
 );
\ No newline at end of file
@@ -9,4 +9,5 @@ require (
 	github.com/joho/godotenv v1.4.0
 	github.com/lib/pq v1.10.9
 	golang.org/x/crypto v0.37.0
+	golang.org/x/net v0.39.0
 )
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"gophermatebackend/internal/db"
 	"gophermatebackend/internal/model"
@@ -39,14 +44,14 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionToken, err := db.CreateSession(createdUser.ID)
+	tokens, err := db.CreateSession(createdUser.ID, r.UserAgent(), clientIP(r))
 	if err != nil {
 		log.Printf("RegisterHandler: Failed to create session: %v\n", err)
 		http.Error(w, "Failed to create session", http.StatusInternalServerError)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, map[string]string{"message": "User registered successfully", "token": sessionToken})
+	writeSessionTokens(w, http.StatusCreated, "User registered successfully", tokens)
 }
 
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -80,24 +85,180 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionToken, err := db.CreateSession(user.ID)
+	tokens, err := db.CreateSession(user.ID, r.UserAgent(), clientIP(r))
 	if err != nil {
 		log.Printf("LoginHandler: Failed to create session: %v\n", err)
 		http.Error(w, "Failed to login", http.StatusInternalServerError)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "User logged in successfully", "token": sessionToken})
+	writeSessionTokens(w, http.StatusOK, "User logged in successfully", tokens)
 }
 
+// RefreshHandler handles POST /api/refresh. It exchanges the refresh_token in the body for a new
+// session token and refresh token; the old ones stop working. A refresh token can only be used once,
+// using it again revokes the session.
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Missing refresh token"})
+		return
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	tokens, err := db.RefreshSession(dbConn, req.RefreshToken)
+	if errors.Is(err, db.ErrSessionExpired) {
+		// Distinct from token_expired so clients log in again instead of retrying the refresh
+		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Refresh token expired", "code": "refresh_token_expired"})
+		return
+	}
+	if err == sql.ErrNoRows || errors.Is(err, db.ErrRefreshTokenReused) {
+		writeTokenError(w, err)
+		return
+	}
+	if err != nil {
+		utils.LogError("RefreshHandler: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to refresh session"})
+		return
+	}
+	writeSessionTokens(w, http.StatusOK, "Session refreshed", tokens)
+}
+
+// writeSessionTokens writes the tokens of a new or refreshed session. expires_in is the number of
+// seconds the session token stays valid if it is not used.
+func writeSessionTokens(w http.ResponseWriter, status int, message string, tokens db.SessionTokens) {
+	utils.WriteJSON(w, status, map[string]interface{}{
+		"message":       message,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(time.Until(tokens.ExpiresAt).Seconds()),
+	})
+}
+
+// writeTokenError writes the 401 response for a session token that failed to authenticate. The code
+// tells clients whether to refresh the session (token_expired) or to log in again.
+func writeTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, db.ErrSessionExpired):
+		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Session expired", "code": "token_expired"})
+	case errors.Is(err, db.ErrRefreshTokenReused):
+		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Refresh token reused, session revoked", "code": "refresh_token_reused"})
+	case err == errMissingToken:
+		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Missing session token", "code": "missing_token"})
+	default:
+		if err != nil && err != sql.ErrNoRows {
+			utils.LogError("writeTokenError: " + err.Error())
+		}
+		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid user token", "code": "invalid_token"})
+	}
+}
+
+// LogoutHandler handles POST /api/logout. It revokes the session token of the request, so it stops
+// working right away. Expired tokens can be logged out too.
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	// Placeholder for user logout logic
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "User logged out successfully"})
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := authToken(r)
+	if token == "" {
+		writeTokenError(w, errMissingToken)
+		return
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	if err := db.RevokeSession(dbConn, token); err != nil {
+		utils.LogError("LogoutHandler: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to logout"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "User logged out successfully"})
+}
+
+// LogoutAllHandler handles POST /api/logout/all, which revokes every session of the user, on all devices.
+func LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	count, err := db.RevokeUserSessions(dbConn, userID)
+	if err != nil {
+		utils.LogError("LogoutAllHandler: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to logout"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{"message": "Logged out of all sessions", "revoked": count})
 }
 
+// clientIP returns the address of the client, as reported by a reverse proxy in X-Forwarded-For if any.
+// It is only recorded to help users recognize their sessions.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(first)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// MeHandler handles GET /api/me, the profile of the authenticated user including their email.
 func MeHandler(w http.ResponseWriter, r *http.Request) {
-	// Placeholder for fetching current user info
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "User info fetched successfully"})
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	profile, err := db.GetProfileByID(dbConn, userID)
+	if err != nil {
+		utils.LogError("MeHandler: Failed to get profile: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+		return
+	}
+	writeProfile(w, dbConn, profile, true)
 }
@@ -1,15 +1,26 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"gophermatebackend/internal/cache"
 	"gophermatebackend/internal/db"
 	"gophermatebackend/internal/utils"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// BoardStateHandler handles GET /api/games/{id}/board
+const (
+	defaultBoardWait = 30 * time.Second
+	maxBoardWait     = 60 * time.Second
+)
+
+// BoardStateHandler handles GET /api/games/{id}/board. With ?after=<move_number> the request is held
+// until a move after that number is made, the game ends, or the wait (?wait=30s by default) elapses.
 func BoardStateHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse game ID from URL: /api/games/{id}/board
 	parts := strings.Split(r.URL.Path, "/")
@@ -19,28 +30,20 @@ func BoardStateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	gameID := parts[3]
 
-	// Authenticate user from Authorization header (Bearer <token>)
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Missing or invalid Authorization header"})
-		return
-	}
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-
 	dbConn, err := db.InitDB()
 	if err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
 		return
 	}
 
-	userID, err := db.GetUserIDBySessionToken(dbConn, token)
+	userID, err := authenticatedUser(r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid user token"})
+		writeTokenError(w, err)
 		return
 	}
 
-	// Check user is part of the game (optional, for security)
-	ok, err := db.ValidateUserInGameSession(dbConn, gameID, userID)
+	// Check user is part of the game or imported it
+	ok, err := db.CanViewGame(dbConn, gameID, userID)
 	if err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
 		return
@@ -50,6 +53,44 @@ func BoardStateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if afterParam := r.URL.Query().Get("after"); afterParam != "" {
+		after, err := strconv.Atoi(afterParam)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid after parameter"})
+			return
+		}
+		wait := defaultBoardWait
+		if waitParam := r.URL.Query().Get("wait"); waitParam != "" {
+			wait, err = time.ParseDuration(waitParam)
+			if err != nil || wait < 0 {
+				utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid wait parameter"})
+				return
+			}
+		}
+		if wait > maxBoardWait {
+			wait = maxBoardWait
+		}
+		if err := waitForMove(r.Context(), dbConn, gameID, after, wait); err != nil {
+			if r.Context().Err() == nil {
+				utils.LogError("BoardStateHandler: Failed to wait for move: " + err.Error())
+				utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load game"})
+			}
+			return
+		}
+	}
+
+	// Make sure the board is cached again if it was evicted, finished games have none
+	board, err := db.LoadBoardCopy(dbConn, gameID)
+	if errors.Is(err, db.ErrUnreplayable) {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Game can no longer be replayed"})
+		return
+	}
+	if err != nil {
+		utils.LogError("BoardStateHandler: Failed to load board: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load game"})
+		return
+	}
+
 	// Get last move for this game using db.GetLastMove
 	moveNumber, notation, err := db.GetLastMove(dbConn, gameID)
 	if err != nil {
@@ -58,12 +99,54 @@ func BoardStateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	resp := map[string]interface{}{
 		"number":   moveNumber,
-		"notation": notation, // format is: white-pawn e2->e4
+		"notation": notation, // SAN (e.g., Nf3); games played before SAN support use white-pawn e2->e4
+	}
+	if board != nil {
+		resp["fen"] = cache.ToFEN(board)
+		if clock := clockState(board, true); clock != nil {
+			resp["clock"] = clock
+		}
 	}
-	board := cache.GetBoard(gameID)
 	if board != nil && board.DrawOfferPending {
 		resp["draw_offer"] = board.DrawOffer
 	}
+	// Boards are cleared once a game ends, so report the stored result
+	if board == nil {
+		winner, reason, err := db.GetGameResult(dbConn, gameID)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get game result"})
+			return
+		}
+		if winner != "" {
+			resp["winner"] = winner
+			resp["reason"] = reason
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
+
+// waitForMove blocks until the game has a move numbered above after, the game has ended,
+// or the wait elapses. The board is re-checked every time it changes in the cache.
+func waitForMove(ctx context.Context, dbConn *sql.DB, gameID string, after int, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		changed := cache.BoardChanged(gameID)
+		board, err := db.LoadBoardCopy(dbConn, gameID)
+		if err != nil {
+			return err
+		}
+		// Finished games have no board
+		if board == nil || board.LastMoveNumber > after {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// ChallengesHandler handles direct challenges:
+// POST /api/challenges sends a challenge to a user, GET /api/challenges lists the challenges sent and received,
+// and POST /api/challenges/{id}/accept, /decline or /cancel answers one. The challenger learns the answer,
+// and the game_id of an accepted challenge, from the outgoing challenges of the listing.
+func ChallengesHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/api/challenges" && r.Method == http.MethodPost:
+		createChallenge(w, r)
+	case path == "/api/challenges" && r.Method == http.MethodGet:
+		listChallenges(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/accept"):
+		acceptChallenge(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/decline"):
+		answerChallenge(w, r, "declined")
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/cancel"):
+		answerChallenge(w, r, "cancelled")
+	default:
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	}
+}
+
+func createChallenge(w http.ResponseWriter, r *http.Request) {
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	var req struct {
+		Username    string `json:"username"`     // User being challenged
+		TimeControl string `json:"time_control"` // Optional: "5+3", "3d" or "" for untimed games
+		Color       string `json:"color"`        // Optional: challenger's color, "white", "black" or "random" (default)
+		Rated       bool   `json:"rated"`        // Optional: rated game, casual by default
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	recipient, err := db.GetUserByUsername(req.Username)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+	if int64(recipient.ID) == userID {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "You cannot challenge yourself"})
+		return
+	}
+
+	timeControl, err := cache.ParseTimeControl(req.TimeControl)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid time control: " + err.Error()})
+		return
+	}
+	if req.Rated {
+		if err := checkRated(timeControl, ""); err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	color := strings.ToLower(req.Color)
+	if color == "" {
+		color = "random"
+	}
+	if _, err := resolveColor(color); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	expiry := utils.LoadConfig().ChallengeExpiry
+	id, err := db.CreateChallenge(dbConn, userID, int64(recipient.ID), timeControl.String(), color, req.Rated, expiry)
+	if err != nil {
+		utils.LogError("createChallenge: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create challenge"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":         id,
+		"expires_in": int(expiry.Seconds()),
+	})
+}
+
+func listChallenges(w http.ResponseWriter, r *http.Request) {
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	challenges, err := db.GetUserChallenges(dbConn, userID)
+	if err != nil {
+		utils.LogError("listChallenges: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get challenges"})
+		return
+	}
+
+	incoming := []map[string]interface{}{}
+	outgoing := []map[string]interface{}{}
+	for _, c := range challenges {
+		if c.RecipientID == userID {
+			incoming = append(incoming, challengeResponse(c))
+		} else {
+			outgoing = append(outgoing, challengeResponse(c))
+		}
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{"incoming": incoming, "outgoing": outgoing})
+}
+
+func acceptChallenge(w http.ResponseWriter, r *http.Request) {
+	dbConn, userID, challengeID, ok := challengeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	challenge, err := db.GetChallenge(dbConn, challengeID)
+	if err == sql.ErrNoRows || (err == nil && challenge.RecipientID != userID) {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Challenge not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("acceptChallenge: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get challenge"})
+		return
+	}
+
+	timeControl, err := cache.ParseTimeControl(challenge.TimeControl)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Invalid challenge time control"})
+		return
+	}
+	challengerColor, err := resolveColor(challenge.Color)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Invalid challenge color"})
+		return
+	}
+	whiteID, blackID, color := challenge.ChallengerID, userID, "black"
+	if challengerColor == "black" {
+		whiteID, blackID, color = userID, challenge.ChallengerID, "white"
+	}
+
+	gameID, err := db.AcceptChallenge(dbConn, challengeID, userID, whiteID, blackID, timeControl, challenge.Rated)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Challenge is no longer pending"})
+		return
+	}
+	if err != nil {
+		utils.LogError("acceptChallenge: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to accept challenge"})
+		return
+	}
+
+	board := cache.NewInitialBoard()
+	board.Clock = cache.NewClock(timeControl)
+	cache.SetBoard(gameID, board)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Challenge accepted", "game_id": gameID, "color": color})
+}
+
+func answerChallenge(w http.ResponseWriter, r *http.Request, status string) {
+	dbConn, userID, challengeID, ok := challengeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := db.AnswerChallenge(dbConn, challengeID, userID, status)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "No pending challenge found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("answerChallenge: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update challenge"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Challenge " + status})
+}
+
+// challengeRequest reads the challenge ID from /api/challenges/{id}/{action} and the authenticated user.
+func challengeRequest(w http.ResponseWriter, r *http.Request) (*sql.DB, int64, string, bool) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid challenge URL"})
+		return nil, 0, "", false
+	}
+	challengeID := parts[3]
+	if _, err := uuid.Parse(challengeID); err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Challenge not found"})
+		return nil, 0, "", false
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return nil, 0, "", false
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return nil, 0, "", false
+	}
+	return dbConn, userID, challengeID, true
+}
+
+func challengeResponse(c db.Challenge) map[string]interface{} {
+	resp := map[string]interface{}{
+		"id":           c.ID,
+		"challenger":   c.ChallengerName,
+		"recipient":    c.RecipientName,
+		"time_control": c.TimeControl,
+		"color":        c.Color,
+		"rated":        c.Rated,
+		"status":       c.Status,
+		"created_at":   c.CreatedAt.Format(time.RFC3339),
+		"expires_at":   c.ExpiresAt.Format(time.RFC3339),
+	}
+	if c.GameID.Valid {
+		resp["game_id"] = c.GameID.String
+	}
+	if c.AnsweredAt.Valid {
+		resp["answered_at"] = c.AnsweredAt.Time.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+
+	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/movevalidation"
+	"gophermatebackend/internal/utils"
+)
+
+// clockState describes the clocks of a game for API responses, or nil for untimed games.
+// Remaining times include the time already spent by the side to move when running is true.
+func clockState(board *cache.Board, running bool) map[string]interface{} {
+	clock := board.Clock
+	if !clock.TimeControl.IsTimed() && clock.TimeControl.DaysPerMove == 0 {
+		return nil
+	}
+	sideToMove := movevalidation.SideToMove(board)
+	now := time.Now()
+	if !running {
+		// Finished games are frozen at the last stored times
+		clock.TurnStartedAt = time.Time{}
+	}
+	state := map[string]interface{}{
+		"time_control": clock.TimeControl.String(),
+		"running":      clock.Running(),
+	}
+	if clock.TimeControl.IsTimed() {
+		state["white_ms"] = clock.Remaining("white", sideToMove, now).Milliseconds()
+		state["black_ms"] = clock.Remaining("black", sideToMove, now).Milliseconds()
+		state["increment_ms"] = clock.TimeControl.Increment.Milliseconds()
+	}
+	if clock.Running() {
+		state["deadline"] = clock.Deadline(sideToMove).UTC().Format(time.RFC3339Nano)
+	}
+	return state
+}
+
+// FinishTimedOutGames ends every game whose side to move has run out of time. The opponent wins.
+// It is run periodically by the flag-fall sweeper in cmd/main.go.
+func FinishTimedOutGames() {
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.LogError("FinishTimedOutGames: Failed to initialize database: " + err.Error())
+		return
+	}
+
+	gameIDs, err := db.GetTimedOutGames(dbConn)
+	if err != nil {
+		utils.LogError("FinishTimedOutGames: " + err.Error())
+		return
+	}
+	for _, gameID := range gameIDs {
+		finishTimedOutGame(dbConn, gameID)
+	}
+}
+
+// finishTimedOutGame ends the game if its side to move has run out of time, under the board's lock
+// so a move being processed right now is either applied first or rejected.
+func finishTimedOutGame(dbConn *sql.DB, gameID string) {
+	unlock := cache.LockBoard(gameID)
+	defer unlock()
+	board, err := db.LoadBoard(dbConn, gameID)
+	if err != nil || board == nil {
+		utils.LogError("FinishTimedOutGames: Failed to load board for game " + gameID)
+		return
+	}
+	// The stored deadline may be stale if the last move was saved after it was read
+	sideToMove := movevalidation.SideToMove(board)
+	if !board.Clock.Flagged(sideToMove, time.Now()) {
+		return
+	}
+	winner := "white"
+	if sideToMove == "white" {
+		winner = "black"
+	}
+	if err := finishGame(dbConn, gameID, winner, "timeout"); err != nil {
+		utils.LogError("FinishTimedOutGames: Failed to finish game " + gameID + ": " + err.Error())
+		return
+	}
+	utils.LogInfo("FinishTimedOutGames: " + sideToMove + " lost on time in game " + gameID)
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/events"
+	"gophermatebackend/internal/movevalidation"
+	"gophermatebackend/internal/utils"
+)
+
+const sseKeepAliveInterval = 30 * time.Second
+
+// GameEventsHandler handles GET /api/games/{id}/events as a Server-Sent Events stream of the same events
+// pushed over the WebSocket. The event ID is the number of the last move the client has seen, so a client
+// that reconnects with Last-Event-ID receives the moves it missed from the moves table before live events.
+func GameEventsHandler(w http.ResponseWriter, r *http.Request) {
+	// Parse game ID from URL: /api/games/{id}/events
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid events URL"})
+		return
+	}
+	gameID := parts[3]
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	ok, err := db.CanViewGame(dbConn, gameID, userID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+	if !ok {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "User is not part of the game session"})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Streaming not supported"})
+		return
+	}
+
+	// Subscribe before reading the moves table so no move falls between the catch-up and the live events
+	ch, unsubscribe := events.Subscribe(gameID)
+	defer unsubscribe()
+
+	game, err := db.GetGame(dbConn, gameID)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("GameEventsHandler: Failed to get game: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get game"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	lastID := -1
+	if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && id >= 0 {
+		lastID = id
+	}
+	if lastID >= 0 {
+		missed, err := missedMoveEvents(dbConn, game, lastID)
+		if err != nil {
+			utils.LogError("GameEventsHandler: Failed to replay moves of game " + gameID + ": " + err.Error())
+			return
+		}
+		for _, event := range missed {
+			lastID = event.Data["number"].(int)
+			writeSSEEvent(w, lastID, event)
+		}
+	} else {
+		lastID, _, err = db.GetLastMove(dbConn, gameID)
+		if err != nil {
+			utils.LogError("GameEventsHandler: Failed to get last move: " + err.Error())
+			return
+		}
+		// Tell the client the current position of the stream so it can resume from here
+		fmt.Fprintf(w, "id: %d\n\n", lastID)
+	}
+
+	if game.FinishedAt.Valid {
+		writeSSEEvent(w, lastID, events.Event{Type: events.TypeFinished, GameID: gameID, Data: map[string]interface{}{
+			"winner": game.Winner.String,
+			"reason": game.ResultReason.String,
+		}})
+		flusher.Flush()
+		return
+	}
+	if board := cache.GetBoardCopy(gameID); board != nil && board.DrawOfferPending {
+		writeSSEEvent(w, lastID, events.Event{Type: events.TypeDrawOffer, GameID: gameID, Data: map[string]interface{}{"offered_by": board.DrawOffer}})
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(sseKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-ch:
+			if event.Type == events.TypeMove {
+				number, _ := event.Data["number"].(int)
+				if number <= lastID {
+					continue // already sent while catching up
+				}
+				lastID = number
+			}
+			writeSSEEvent(w, lastID, event)
+			flusher.Flush()
+			if event.Type == events.TypeFinished {
+				return
+			}
+		case <-ticker.C:
+			// Comment line to keep proxies from closing an idle stream
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent writes one event in the text/event-stream format.
+func writeSSEEvent(w http.ResponseWriter, id int, event events.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		utils.LogError("writeSSEEvent: Failed to encode event: " + err.Error())
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event.Type, data)
+}
+
+// missedMoveEvents replays the game's moves and returns a move event for each move after the given number,
+// with the same data MoveHandler publishes.
+func missedMoveEvents(dbConn *sql.DB, game *db.Game, after int) ([]events.Event, error) {
+	board, err := db.StartingBoard(game.InitialFEN.String)
+	if err != nil {
+		return nil, err
+	}
+
+	moves, err := db.GetMoves(dbConn, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	var missed []events.Event
+	err = db.ReplayMoves(board, moves, func(m db.ReplayedMove) error {
+		if m.Number <= after {
+			return nil
+		}
+		missed = append(missed, events.Event{Type: events.TypeMove, GameID: game.ID, Data: map[string]interface{}{
+			"number":   m.Number,
+			"color":    m.Color,
+			"notation": m.SAN,
+			"uci":      movevalidation.UCI(m.Move),
+			"fen":      cache.ToFEN(board),
+		}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return missed, nil
+}
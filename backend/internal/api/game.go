@@ -1,15 +1,22 @@
 package api
 
 import (
+	crand "crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"gophermatebackend/internal/cache"
 	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/events"
 	"gophermatebackend/internal/movevalidation"
 	"gophermatebackend/internal/utils"
 )
@@ -29,17 +36,9 @@ func AcceptDrawHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	gameID := parts[3]
 
-	var req struct {
-		PlayerToken string `json:"player_token"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
-	}
-
-	userID, err := db.GetUserIDBySessionToken(dbConn, req.PlayerToken)
+	userID, err := authenticatedUser(r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid session token"})
+		writeTokenError(w, err)
 		return
 	}
 
@@ -48,26 +47,45 @@ func AcceptDrawHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "User not in game"})
 		return
 	}
+	color, err := db.GetUserColorInGame(dbConn, gameID, userID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to determine player color"})
+		return
+	}
 
-	board := cache.GetBoard(gameID)
+	unlock := cache.LockBoard(gameID)
+	defer unlock()
+	board, err := db.LoadBoard(dbConn, gameID)
+	if err != nil {
+		utils.LogError("AcceptDrawHandler: Failed to load board: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load game"})
+		return
+	}
 	if board == nil {
 		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
 		return
 	}
 
+	if !hasDrawOfferFromOpponent(board, color) {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "No pending draw offer from your opponent"})
+		return
+	}
+
 	// Accept draw: clear draw offer
 	board.DrawOffer = ""
 	board.DrawOfferPending = false
 
-	// Update DB: set finished_at and winner
-	if err := db.SetGameDraw(dbConn, gameID); err != nil {
+	// Update DB: set finished_at and winner, and clear board cache for completed game
+	err = finishGame(dbConn, gameID, "draw", "agreement")
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Game is already finished"})
+		return
+	}
+	if err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update game"})
 		return
 	}
 
-	// Clear board cache for completed game
-	cache.ClearBoard(gameID)
-
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Draw accepted, game ended"})
 }
 
@@ -86,17 +104,9 @@ func DeclineDrawHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	gameID := parts[3]
 
-	var req struct {
-		PlayerToken string `json:"player_token"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
-	}
-
-	userID, err := db.GetUserIDBySessionToken(dbConn, req.PlayerToken)
+	userID, err := authenticatedUser(r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid session token"})
+		writeTokenError(w, err)
 		return
 	}
 
@@ -105,20 +115,44 @@ func DeclineDrawHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "User not in game"})
 		return
 	}
+	color, err := db.GetUserColorInGame(dbConn, gameID, userID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to determine player color"})
+		return
+	}
 
-	board := cache.GetBoard(gameID)
+	unlock := cache.LockBoard(gameID)
+	defer unlock()
+	board, err := db.LoadBoard(dbConn, gameID)
+	if err != nil {
+		utils.LogError("DeclineDrawHandler: Failed to load board: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load game"})
+		return
+	}
 	if board == nil {
 		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
 		return
 	}
 
+	if !hasDrawOfferFromOpponent(board, color) {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "No pending draw offer from your opponent"})
+		return
+	}
+
 	// Decline draw: clear draw offer
+	offeredBy := board.DrawOffer
 	board.DrawOffer = ""
 	board.DrawOfferPending = false
+	events.Publish(gameID, events.Event{Type: events.TypeDrawDeclined, Data: map[string]interface{}{"offered_by": offeredBy}})
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Draw offer declined"})
 }
 
+// hasDrawOfferFromOpponent reports whether the player of color has a draw offer to answer.
+func hasDrawOfferFromOpponent(board *cache.Board, color string) bool {
+	return board.DrawOfferPending && board.DrawOffer != "" && board.DrawOffer != color
+}
+
 // OfferDrawHandler handles POST /api/games/:id/offer-draw
 func OfferDrawHandler(w http.ResponseWriter, r *http.Request) {
 	dbConn, err := db.InitDB()
@@ -135,19 +169,10 @@ func OfferDrawHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	gameID := parts[3]
 
-	// Parse session token from request body
-	var req struct {
-		PlayerToken string `json:"player_token"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
-	}
-
-	// Get user ID from session token
-	userID, err := db.GetUserIDBySessionToken(dbConn, req.PlayerToken)
+	// Get the user authenticated from the session token
+	userID, err := authenticatedUser(r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid user token"})
+		writeTokenError(w, err)
 		return
 	}
 
@@ -173,7 +198,14 @@ func OfferDrawHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	board := cache.GetBoard(gameID)
+	unlock := cache.LockBoard(gameID)
+	defer unlock()
+	board, err := db.LoadBoard(dbConn, gameID)
+	if err != nil {
+		utils.LogError("OfferDrawHandler: Failed to load board: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load game"})
+		return
+	}
 	if board == nil {
 		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
 		return
@@ -182,10 +214,20 @@ func OfferDrawHandler(w http.ResponseWriter, r *http.Request) {
 	// Set draw offer
 	board.DrawOffer = color
 	board.DrawOfferPending = true
+	events.Publish(gameID, events.Event{Type: events.TypeDrawOffer, Data: map[string]interface{}{"offered_by": color}})
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Draw offer sent"})
 }
 
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// GamesHandler handles GET /api/games, the lobby. It lists unfinished games, newest first, a page at a time.
+// Query parameters: waiting=true (only games with an empty seat), time_control=5+3|3d|untimed, mode=rated|casual,
+// min_rating and max_rating, mine=true (the caller's games, requires authentication),
+// sort=newest|oldest|rating, limit (20 by default, at most 100) and cursor (next_cursor of the previous page).
 func GamesHandler(w http.ResponseWriter, r *http.Request) {
 	dbConn, err := db.InitDB()
 	if err != nil {
@@ -194,7 +236,25 @@ func GamesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	games, err := db.GetOpenGames(dbConn)
+	filter, err := lobbyFilter(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if r.URL.Query().Get("mine") == "true" {
+		userID, err := authenticatedUser(r)
+		if err != nil {
+			writeTokenError(w, err)
+			return
+		}
+		filter.UserID = userID
+	}
+
+	games, nextCursor, err := db.GetOpenGames(dbConn, filter)
+	if err == db.ErrInvalidCursor {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		log.Printf("GamesHandler: Failed to fetch games: %v", err)
 		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch games"})
@@ -202,14 +262,124 @@ func GamesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	response := make([]map[string]interface{}, len(games))
 	for i, game := range games {
+		status := "in_progress"
+		if !game.PlayerWhite.Valid || !game.PlayerBlack.Valid {
+			status = "waiting"
+		}
 		response[i] = map[string]interface{}{
 			"id":           game.ID,
-			"player_white": game.PlayerWhite.Int64,
-			"player_black": game.PlayerBlack.Int64,
+			"status":       status,
+			"white":        lobbyPlayer(game.PlayerWhite, game.WhiteUsername, game.WhiteRating, game.WhiteProvisional),
+			"black":        lobbyPlayer(game.PlayerBlack, game.BlackUsername, game.BlackRating, game.BlackProvisional),
+			"time_control": game.TimeControl.String,
+			"rated":        game.Rated,
+			"rating":       game.Rating,
+			"created_at":   game.CreatedAt.Format(time.RFC3339),
 		}
 	}
 
-	utils.WriteJSON(w, http.StatusOK, response)
+	var next interface{}
+	if nextCursor != "" {
+		next = nextCursor
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{"games": response, "next_cursor": next})
+}
+
+// lobbyFilter reads the lobby query parameters, except mine which needs the caller to be authenticated.
+func lobbyFilter(r *http.Request) (db.LobbyFilter, error) {
+	query := r.URL.Query()
+	filter := db.LobbyFilter{
+		WaitingOnly: query.Get("waiting") == "true",
+		Mode:        query.Get("mode"),
+		Sort:        query.Get("sort"),
+		Cursor:      query.Get("cursor"),
+	}
+
+	switch filter.Mode {
+	case "", db.LobbyRated, db.LobbyCasual:
+	default:
+		return filter, errors.New("Invalid mode, expected rated or casual")
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = db.LobbySortNewest
+	case db.LobbySortNewest, db.LobbySortOldest, db.LobbySortRating:
+	default:
+		return filter, errors.New("Invalid sort, expected newest, oldest or rating")
+	}
+
+	if tc := query.Get("time_control"); tc == db.LobbyUntimed {
+		filter.TimeControl = db.LobbyUntimed
+	} else if tc != "" {
+		// An unescaped + in the query string decodes to a space
+		timeControl, err := cache.ParseTimeControl(strings.ReplaceAll(tc, " ", "+"))
+		if err != nil {
+			return filter, errors.New("Invalid time control: " + err.Error())
+		}
+		filter.TimeControl = timeControl.String()
+	}
+
+	var err error
+	if filter.Limit, err = pageLimit(r); err != nil {
+		return filter, err
+	}
+	if filter.MinRating, err = queryInt(query.Get("min_rating")); err != nil {
+		return filter, errors.New("Invalid min_rating")
+	}
+	if filter.MaxRating, err = queryInt(query.Get("max_rating")); err != nil {
+		return filter, errors.New("Invalid max_rating")
+	}
+	if filter.MaxRating > 0 && filter.MinRating > filter.MaxRating {
+		return filter, errors.New("Invalid rating range")
+	}
+
+	return filter, nil
+}
+
+// pageLimit reads the ?limit= page size of a paginated list, 20 by default and at most 100.
+func pageLimit(r *http.Request) (int, error) {
+	limit, err := queryInt(r.URL.Query().Get("limit"))
+	if err != nil {
+		return 0, errors.New("Invalid limit")
+	}
+	if limit == 0 {
+		return defaultPageLimit, nil
+	}
+	if limit > maxPageLimit {
+		return maxPageLimit, nil
+	}
+	return limit, nil
+}
+
+// queryInt parses an optional non-negative query parameter, 0 when absent.
+func queryInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid number")
+	}
+	return n, nil
+}
+
+// lobbyPlayer describes a seat of a lobby game, nil when it is empty.
+// Players of untimed games have no rating.
+func lobbyPlayer(id sql.NullInt64, username sql.NullString, rating sql.NullInt64, provisional bool) interface{} {
+	if !id.Valid {
+		return nil
+	}
+	player := map[string]interface{}{
+		"id":       id.Int64,
+		"username": username.String,
+		"rating":   nil,
+	}
+	if rating.Valid {
+		player["rating"] = rating.Int64
+		player["provisional"] = provisional
+	}
+	return player
 }
 
 func JoinGameHandler(w http.ResponseWriter, r *http.Request) {
@@ -228,41 +398,97 @@ func JoinGameHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	gameID := parts[3]
 
-	// Parse session token from request body
-	var req struct {
-		PlayerToken string `json:"player_token"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
-	}
-
-	// Get user ID from session token
-	userID, err := db.GetUserIDBySessionToken(dbConn, req.PlayerToken)
+	// Get the user authenticated from the session token
+	userID, err := authenticatedUser(r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid user token"})
+		writeTokenError(w, err)
 		return
 	}
 
-	// Attempt to join the game as black
-	err = db.JoinGameAsBlack(dbConn, gameID, userID)
+	// Attempt to join the game in whichever seat is empty
+	color, err := db.JoinGame(dbConn, gameID, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
+			utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found or already full"})
 			return
 		}
 		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Joined game successfully"})
+	events.Publish(gameID, events.Event{Type: events.TypeJoined, Data: map[string]interface{}{"color": color}})
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Joined game successfully", "color": color})
+}
+
+// InviteJoinHandler handles POST /api/invites/{code}/join for private games.
+func InviteJoinHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse invite code from URL: /api/invites/{code}/join
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 || parts[4] != "join" {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid invite URL"})
+		return
+	}
+	inviteCode := parts[3]
+	if !validInviteCode(inviteCode) {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Invite not found or game already full"})
+		return
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	gameID, color, err := db.JoinGameByInviteCode(dbConn, inviteCode, userID)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Invite not found or game already full"})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to join game"})
+		return
+	}
+
+	events.Publish(gameID, events.Event{Type: events.TypeJoined, Data: map[string]interface{}{"color": color}})
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Joined game successfully", "id": gameID, "color": color})
+}
+
+// inviteCodeSize is the number of random bytes in an invite code.
+const inviteCodeSize = 16
+
+// newInviteCode returns an unguessable code for a private game.
+func newInviteCode() (string, error) {
+	b := make([]byte, inviteCodeSize)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// validInviteCode reports whether code has the form of a code made by newInviteCode.
+func validInviteCode(code string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(code)
+	return err == nil && len(b) == inviteCodeSize
 }
 
 func MoveHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse request body
 	var moveReq struct {
 		Session string `json:"session"`
-		User    string `json:"user"`
 		Piece   string `json:"piece"`
 		From    struct {
 			Row int `json:"row"`
@@ -272,6 +498,8 @@ func MoveHandler(w http.ResponseWriter, r *http.Request) {
 			Row int `json:"row"`
 			Col int `json:"col"`
 		} `json:"to"`
+		Promotion string `json:"promotion"` // Optional: "queen", "rook", "bishop" or "knight"
+		Move      string `json:"move"`      // Optional: SAN ("Nf3") or UCI ("g1f3") instead of piece/from/to
 	}
 	if err := json.NewDecoder(r.Body).Decode(&moveReq); err != nil {
 		utils.LogError("MoveHandler: failed to decode request body: " + err.Error())
@@ -286,12 +514,10 @@ func MoveHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate that the incoming User(token) from the data matches a existing user session from the database
-	utils.LogDebug("MoveHandler: db.GetUserIDBySessionToken params: sessionToken=" + moveReq.User)
-	userID, err := db.GetUserIDBySessionToken(dbConn, moveReq.User)
+	// Validate that the request is authenticated
+	userID, err := authenticatedUser(r)
 	if err != nil {
-		utils.LogError("MoveHandler: failed to get user ID by session token: " + err.Error())
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid user token"})
+		writeTokenError(w, err)
 		return
 	}
 
@@ -322,7 +548,15 @@ func MoveHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	board := cache.GetBoard(moveReq.Session)
+	// Held until the move is saved and applied, so two moves cannot both pass validation
+	unlock := cache.LockBoard(moveReq.Session)
+	defer unlock()
+	board, err := db.LoadBoard(dbConn, moveReq.Session)
+	if err != nil {
+		utils.LogError("MoveHandler: failed to load board: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load game"})
+		return
+	}
 	if board == nil {
 		utils.LogError("MoveHandler: board is nil")
 		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
@@ -330,64 +564,124 @@ func MoveHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Determine whose turn it is using board.LastMove
-	var turnColor string
-	switch board.LastMove {
-	case "white":
-		turnColor = "black"
-	case "black":
-		turnColor = "white"
-	default:
-		turnColor = "white" // fallback to white if unset
-	}
-	if color != turnColor {
+	if color != movevalidation.SideToMove(board) {
 		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "It is not your turn"})
 		return
 	}
 
+	// A move arriving after the flag fell loses on time, even if the sweeper has not caught it yet
+	now := time.Now()
+	if board.Clock.Flagged(color, now) {
+		winner := "white"
+		if color == "white" {
+			winner = "black"
+		}
+		if err := finishGame(dbConn, moveReq.Session, winner, "timeout"); err != nil {
+			utils.LogError("MoveHandler: Failed to finish game: " + err.Error())
+			utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to finish game"})
+			return
+		}
+		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "Time is up", "winner": winner, "reason": "timeout"})
+		return
+	}
+
 	// Validate move
-	valid, err := movevalidation.ValidateMove(board, movevalidation.MoveData{
-		Piece: moveReq.Piece,
-		From:  movevalidation.Position{Row: moveReq.From.Row, Col: moveReq.From.Col},
-		To:    movevalidation.Position{Row: moveReq.To.Row, Col: moveReq.To.Col},
-	})
+	move := movevalidation.MoveData{
+		Piece:     moveReq.Piece,
+		From:      movevalidation.Position{Row: moveReq.From.Row, Col: moveReq.From.Col},
+		To:        movevalidation.Position{Row: moveReq.To.Row, Col: moveReq.To.Col},
+		Promotion: strings.ToLower(moveReq.Promotion),
+	}
+	if moveReq.Move != "" {
+		move, err = movevalidation.ParseMove(board, moveReq.Move)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	valid, err := movevalidation.ValidateMove(board, move)
 	if err != nil {
 		utils.LogError("MoveHandler: Invalid move: " + err.Error())
 		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 	if !valid {
-		utils.LogError("MoveHandler: Invalid move: " + err.Error())
+		utils.LogError("MoveHandler: Invalid move")
 		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid move"})
 		return
 	}
 
-	// Build notation: color-piece e2->e4
-	from := string(rune('a'+moveReq.From.Col)) + string(rune('1'+(7-moveReq.From.Row)))
-	to := string(rune('a'+moveReq.To.Col)) + string(rune('1'+(7-moveReq.To.Row)))
-	notation := moveReq.Piece + " " + from + "->" + to
+	// Build notation: SAN (e.g., Nf3, O-O, e8=Q+) plus the UCI form (e.g., g1f3)
+	notation := movevalidation.SAN(board, move)
+	uci := movevalidation.UCI(move)
+
+	// Play the move on a copy first, the cached board only changes once the move and clock are saved
+	// (ApplyMove also moves the castling rook and removes a pawn captured en passant)
+	next := board.Copy()
+	movevalidation.ApplyMove(next, move)
+	next.Clock.Punch(color, now)
 
-	err = db.SaveMove(dbConn, moveReq.Session, userID, notation)
+	err = db.SaveMove(dbConn, moveReq.Session, userID, notation, uci, next.Clock, movevalidation.SideToMove(next))
+	if errors.Is(err, db.ErrGameFinished) {
+		cache.ClearBoard(moveReq.Session)
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Game is already finished"})
+		return
+	}
+	if errors.Is(err, db.ErrGameNotStarted) {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Waiting for an opponent to join"})
+		return
+	}
 	if err != nil {
 		utils.LogError("MoveHandler: Failed to save move: " + err.Error())
 		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save move"})
 		return
 	}
-
-	// Update board state in cache
-	board.Squares[moveReq.To.Row][moveReq.To.Col] = moveReq.Piece
-	board.Squares[moveReq.From.Row][moveReq.From.Col] = "" // Clear the from square
-	if color == "white" {
-		board.LastMove = "white"
-	} else {
-		board.LastMove = "black"
-	}
+	*board = *next
 
 	// Update last move information in cache
 	board.LastMoveNumber = board.LastMoveNumber + 1
 	board.LastMoveNotation = notation
 	cache.SetBoard(moveReq.Session, board)
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Move submitted successfully"})
+	events.Publish(moveReq.Session, events.Event{Type: events.TypeMove, Data: map[string]interface{}{
+		"number":   board.LastMoveNumber,
+		"color":    color,
+		"notation": notation,
+		"uci":      uci,
+		"fen":      cache.ToFEN(board),
+	}})
+
+	// End the game if the opponent has no legal reply
+	resp := map[string]interface{}{"message": "Move submitted successfully", "notation": notation, "uci": uci, "fen": cache.ToFEN(board)}
+	if clock := clockState(board, true); clock != nil {
+		resp["clock"] = clock
+	}
+	if status := movevalidation.GameStatus(board); status != "" {
+		winner := "draw"
+		if status == movevalidation.StatusCheckmate {
+			winner = color
+		}
+		if err := finishGame(dbConn, moveReq.Session, winner, status); err != nil {
+			utils.LogError("MoveHandler: Failed to finish game: " + err.Error())
+			utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to finish game"})
+			return
+		}
+		resp["winner"] = winner
+		resp["reason"] = status
+	}
+
+	utils.WriteJSON(w, http.StatusOK, resp)
+}
+
+// finishGame records the result of a game in the database, clears its board from the cache
+// and notifies the game's subscribers.
+func finishGame(dbConn *sql.DB, gameID string, winner string, reason string) error {
+	if err := db.SetGameFinished(dbConn, gameID, winner, reason); err != nil {
+		return err
+	}
+	cache.ClearBoard(gameID)
+	events.Publish(gameID, events.Event{Type: events.TypeFinished, Data: map[string]interface{}{"winner": winner, "reason": reason}})
+	return nil
 }
 
 // CreateGameHandler handles POST /api/games
@@ -407,19 +701,10 @@ func ResignHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	gameID := parts[3]
 
-	// Parse session token from request body
-	var req struct {
-		PlayerToken string `json:"player_token"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
-	}
-
-	// Get user ID from session token
-	userID, err := db.GetUserIDBySessionToken(dbConn, req.PlayerToken)
+	// Get the user authenticated from the session token
+	userID, err := authenticatedUser(r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid user token"})
+		writeTokenError(w, err)
 		return
 	}
 
@@ -445,6 +730,20 @@ func ResignHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Wait for a move being processed, so it is not saved after the game ends
+	unlock := cache.LockBoard(gameID)
+	defer unlock()
+
+	game, err := db.GetGame(dbConn, gameID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get game"})
+		return
+	}
+	if game.FinishedAt.Valid {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Game is already finished"})
+		return
+	}
+
 	// Set winner to the opposite color and finished_at to now
 	var winner string
 	if color == "white" {
@@ -452,15 +751,18 @@ func ResignHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		winner = "white"
 	}
-	err = db.SetGameResigned(dbConn, gameID, winner)
+	// Notify subscribers before the game end, then clear board cache for completed game
+	events.Publish(gameID, events.Event{Type: events.TypeResigned, Data: map[string]interface{}{"color": color}})
+	err = finishGame(dbConn, gameID, winner, "resignation")
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Game is already finished"})
+		return
+	}
 	if err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to resign game"})
 		return
 	}
 
-	// Clear board cache for completed game
-	cache.ClearBoard(gameID)
-
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Resigned successfully", "winner": winner})
 }
 
@@ -473,7 +775,11 @@ func CreateGameHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		PlayerToken string `json:"player_token"`
+		FEN         string `json:"fen"`          // Optional custom starting position
+		TimeControl string `json:"time_control"` // Optional: "5+3" (minutes + increment seconds) or "3d" (days per move)
+		Color       string `json:"color"`        // Optional: "white" (default), "black" or "random"
+		Private     bool   `json:"private"`      // Optional: hide the game from the lobby, joinable only by invite code
+		Rated       bool   `json:"rated"`        // Optional: update the players' ratings when the game finishes (casual by default)
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.LogError("CreateGameHandler: Failed to decode request body: " + err.Error())
@@ -481,15 +787,53 @@ func CreateGameHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from session token
-	playerWhiteID, err := db.GetUserIDBySessionToken(dbConn, req.PlayerToken)
-	if err != nil || playerWhiteID <= 0 {
+	// Get the user authenticated from the session token
+	creatorID, err := authenticatedUser(r)
+	if err != nil || creatorID <= 0 {
 		utils.LogError("CreateGameHandler: Invalid player token: " + err.Error())
-		utils.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid player token"})
+		writeTokenError(w, err)
 		return
 	}
 
-	gameID, err := db.CreateGame(dbConn, playerWhiteID)
+	board := cache.NewInitialBoard()
+	if req.FEN != "" {
+		board, err = newBoardFromCustomFEN(req.FEN)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid FEN: " + err.Error()})
+			return
+		}
+	}
+
+	timeControl, err := cache.ParseTimeControl(req.TimeControl)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid time control: " + err.Error()})
+		return
+	}
+	board.Clock = cache.NewClock(timeControl)
+	if req.Rated {
+		if err := checkRated(timeControl, req.FEN); err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	color, err := resolveColor(req.Color)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	inviteCode := ""
+	if req.Private {
+		inviteCode, err = newInviteCode()
+		if err != nil {
+			utils.LogError("CreateGameHandler: Failed to generate invite code: " + err.Error())
+			utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create game"})
+			return
+		}
+	}
+
+	gameID, err := db.CreateGame(dbConn, creatorID, color, req.FEN, timeControl, inviteCode, req.Rated)
 	if err != nil {
 		utils.LogError("CreateGameHandler: Failed to create game: " + err.Error())
 		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create game"})
@@ -497,10 +841,58 @@ func CreateGameHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if a board already exists for this gameID (should not, but for safety)
-	// If not, create and cache a new board
+	// If not, cache the starting board
 	if cache.GetBoard(gameID) == nil {
-		cache.SetBoard(gameID, cache.NewInitialBoard())
+		cache.SetBoard(gameID, board)
+	}
+
+	resp := map[string]string{"id": gameID, "color": color}
+	if inviteCode != "" {
+		resp["invite_code"] = inviteCode
 	}
+	utils.WriteJSON(w, http.StatusOK, resp)
+}
+
+// resolveColor turns a color preference ("white", "black", "random" or "" for white) into the color to play.
+func resolveColor(choice string) (string, error) {
+	switch strings.ToLower(choice) {
+	case "", "white":
+		return "white", nil
+	case "black":
+		return "black", nil
+	case "random":
+		if rand.Intn(2) == 0 {
+			return "black", nil
+		}
+		return "white", nil
+	}
+	return "", errors.New("Invalid color, expected white, black or random")
+}
+
+// checkRated returns an error if a game with this time control and starting position cannot be rated.
+// Untimed games have no rating pool and custom starting positions are not standard chess.
+func checkRated(timeControl cache.TimeControl, fen string) error {
+	if timeControl.Category() == "" {
+		return errors.New("Untimed games cannot be rated")
+	}
+	if fen != "" {
+		return errors.New("Games from a custom position cannot be rated")
+	}
+	return nil
+}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"id": gameID})
+// newBoardFromCustomFEN parses a FEN and rejects positions that cannot start a game:
+// the side that just moved may not be in check, and the side to move must have a legal move.
+func newBoardFromCustomFEN(fen string) (*cache.Board, error) {
+	board, err := cache.NewBoardFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	if movevalidation.IsInCheck(board, board.LastMove) {
+		return nil, errors.New("side not to move is in check")
+	}
+	if movevalidation.GameStatus(board) != "" {
+		return nil, errors.New("side to move has no legal moves")
+	}
+	return board, nil
 }
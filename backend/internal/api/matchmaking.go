@@ -0,0 +1,305 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/events"
+	"gophermatebackend/internal/utils"
+)
+
+const (
+	defaultMatchWait = 30 * time.Second
+	maxMatchWait     = 30 * time.Second
+	// matchmakingTimeout is how long a player waits for an opponent before being sent to an open game
+	matchmakingTimeout = 2 * time.Minute
+	// openGameCandidates is how many open games a timed out player tries to join
+	openGameCandidates = 20
+)
+
+// MatchmakingHandler handles /api/matchmaking.
+// POST joins the queue for a time control and is paired right away if a compatible player is waiting.
+// GET reports the queue status and DELETE leaves the queue. POST and GET hold the request until a match
+// is found or ?wait= elapses (30s by default), so the waiting player is notified as soon as it is paired.
+func MatchmakingHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		joinMatchmaking(w, r)
+	case http.MethodGet:
+		matchmakingStatus(w, r)
+	case http.MethodDelete:
+		cancelMatchmaking(w, r)
+	default:
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	}
+}
+
+func joinMatchmaking(w http.ResponseWriter, r *http.Request) {
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.LogError("joinMatchmaking: Failed to initialize database: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	var req struct {
+		TimeControl string `json:"time_control"` // "5+3", "3d" or "" for untimed games
+		MinRating   int    `json:"min_rating"`   // Optional opponent rating range
+		MaxRating   int    `json:"max_rating"`
+		Rated       bool   `json:"rated"` // Optional: only pair for a rated game, casual by default
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	timeControl, err := cache.ParseTimeControl(req.TimeControl)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid time control: " + err.Error()})
+		return
+	}
+	if req.Rated {
+		if err := checkRated(timeControl, ""); err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if req.MinRating < 0 || req.MaxRating < 0 || (req.MaxRating > 0 && req.MinRating > req.MaxRating) {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid rating range"})
+		return
+	}
+	wait, ok := matchWait(r)
+	if !ok {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid wait parameter"})
+		return
+	}
+
+	rating, err := db.GetUserRating(dbConn, userID, timeControl.Category())
+	if err != nil {
+		utils.LogError("joinMatchmaking: Failed to get rating: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get rating"})
+		return
+	}
+
+	opponent := cache.FindOrQueueMatch(cache.MatchRequest{
+		UserID:      userID,
+		TimeControl: timeControl.String(),
+		Rated:       req.Rated,
+		Rating:      rating.Value(),
+		MinRating:   req.MinRating,
+		MaxRating:   req.MaxRating,
+		QueuedAt:    time.Now(),
+	})
+	if opponent != nil {
+		result, err := createMatchedGame(dbConn, userID, opponent.UserID, timeControl, req.Rated)
+		if err != nil {
+			cache.ReleaseMatch(opponent.UserID)
+			utils.LogError("joinMatchmaking: Failed to create game: " + err.Error())
+			utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create game"})
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, matchResponse(result, false))
+		return
+	}
+
+	writeMatchStatus(w, r, dbConn, userID, wait)
+}
+
+func matchmakingStatus(w http.ResponseWriter, r *http.Request) {
+	dbConn, userID, ok := matchmakingUser(w, r)
+	if !ok {
+		return
+	}
+	wait, ok := matchWait(r)
+	if !ok {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid wait parameter"})
+		return
+	}
+	writeMatchStatus(w, r, dbConn, userID, wait)
+}
+
+func cancelMatchmaking(w http.ResponseWriter, r *http.Request) {
+	_, userID, ok := matchmakingUser(w, r)
+	if !ok {
+		return
+	}
+	if !cache.CancelMatch(userID) {
+		// Too late if an opponent was found in the meantime
+		if _, result, _ := cache.GetMatchStatus(userID); result != nil {
+			utils.WriteJSON(w, http.StatusConflict, matchResponse(*result, false))
+			return
+		}
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Not in the matchmaking queue"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Left the matchmaking queue"})
+}
+
+// matchmakingUser returns the user of an authenticated matchmaking request.
+func matchmakingUser(w http.ResponseWriter, r *http.Request) (*sql.DB, int64, bool) {
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return nil, 0, false
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return nil, 0, false
+	}
+	return dbConn, userID, true
+}
+
+// matchWait reads the ?wait= duration of a matchmaking request.
+func matchWait(r *http.Request) (time.Duration, bool) {
+	waitParam := r.URL.Query().Get("wait")
+	if waitParam == "" {
+		return defaultMatchWait, true
+	}
+	wait, err := time.ParseDuration(waitParam)
+	if err != nil || wait < 0 {
+		return 0, false
+	}
+	if wait > maxMatchWait {
+		wait = maxMatchWait
+	}
+	return wait, true
+}
+
+// writeMatchStatus waits until the user is paired, leaves the queue or the wait elapses, then reports
+// the status. A player still unmatched after matchmakingTimeout is sent to an open game instead.
+func writeMatchStatus(w http.ResponseWriter, r *http.Request, dbConn *sql.DB, userID int64, wait time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	for {
+		req, result, changed := cache.GetMatchStatus(userID)
+		if result != nil {
+			utils.WriteJSON(w, http.StatusOK, matchResponse(*result, false))
+			return
+		}
+		if req == nil {
+			utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "none"})
+			return
+		}
+
+		queuedFor := time.Since(req.QueuedAt)
+		if queuedFor >= matchmakingTimeout && cache.CancelMatch(userID) {
+			fallback, err := joinOpenGame(dbConn, userID, req.TimeControl, req.Rated)
+			if err != nil {
+				utils.LogError("writeMatchStatus: Failed to join an open game: " + err.Error())
+				utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to join an open game"})
+				return
+			}
+			if fallback == nil {
+				utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "timeout"})
+				return
+			}
+			utils.WriteJSON(w, http.StatusOK, matchResponse(*fallback, true))
+			return
+		}
+
+		// While an opponent is creating the game the entry cannot be cancelled, wait for the result
+		untilTimeout := matchmakingTimeout - queuedFor
+		if untilTimeout <= 0 {
+			untilTimeout = time.Second
+		}
+		timeout := time.NewTimer(untilTimeout)
+		select {
+		case <-changed:
+			timeout.Stop()
+		case <-timeout.C:
+		case <-ctx.Done():
+			timeout.Stop()
+			if r.Context().Err() != nil {
+				return
+			}
+			utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+				"status":             "queued",
+				"time_control":       req.TimeControl,
+				"queued_for_seconds": int(time.Since(req.QueuedAt).Seconds()),
+			})
+			return
+		}
+	}
+}
+
+// createMatchedGame creates the game of two paired players with random colors and notifies the waiting opponent.
+// It returns the match from the point of view of the player who completed the pair.
+func createMatchedGame(dbConn *sql.DB, userID int64, opponentID int64, timeControl cache.TimeControl, rated bool) (cache.MatchResult, error) {
+	whiteID, blackID := userID, opponentID
+	if rand.Intn(2) == 0 {
+		whiteID, blackID = opponentID, userID
+	}
+	gameID, err := db.CreateMatchedGame(dbConn, whiteID, blackID, timeControl, rated)
+	if err != nil {
+		return cache.MatchResult{}, err
+	}
+	board := cache.NewInitialBoard()
+	board.Clock = cache.NewClock(timeControl)
+	cache.SetBoard(gameID, board)
+
+	color, opponentColor := "white", "black"
+	if whiteID == opponentID {
+		color, opponentColor = "black", "white"
+	}
+	cache.CompleteMatch(opponentID, cache.MatchResult{GameID: gameID, Color: opponentColor})
+	utils.LogInfo("createMatchedGame: paired players in game " + gameID)
+	return cache.MatchResult{GameID: gameID, Color: color}, nil
+}
+
+// joinOpenGame joins the longest waiting open game with the same time control and rated or casual mode
+// created by another player, if any.
+func joinOpenGame(dbConn *sql.DB, userID int64, timeControl string, rated bool) (*cache.MatchResult, error) {
+	filter := db.LobbyFilter{WaitingOnly: true, TimeControl: timeControl, Mode: db.LobbyCasual, Sort: db.LobbySortOldest, Limit: openGameCandidates}
+	if timeControl == "" {
+		filter.TimeControl = db.LobbyUntimed
+	}
+	if rated {
+		filter.Mode = db.LobbyRated
+	}
+	games, _, err := db.GetOpenGames(dbConn, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, game := range games {
+		// Exactly one seat must be taken, by someone else
+		if game.PlayerWhite.Valid == game.PlayerBlack.Valid {
+			continue
+		}
+		if game.PlayerWhite.Int64 == userID || game.PlayerBlack.Int64 == userID {
+			continue
+		}
+		color, err := db.JoinGame(dbConn, game.ID, userID)
+		if err == sql.ErrNoRows {
+			continue // taken by someone else in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		events.Publish(game.ID, events.Event{Type: events.TypeJoined, Data: map[string]interface{}{"color": color}})
+		return &cache.MatchResult{GameID: game.ID, Color: color}, nil
+	}
+	return nil, nil
+}
+
+func matchResponse(result cache.MatchResult, fallback bool) map[string]interface{} {
+	return map[string]interface{}{
+		"status":   "matched",
+		"game_id":  result.GameID,
+		"color":    result.Color,
+		"fallback": fallback,
+	}
+}
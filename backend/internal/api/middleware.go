@@ -2,24 +2,54 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
+	"gophermatebackend/internal/db"
 	"gophermatebackend/internal/utils"
 )
 
+// sessionCookie is the cookie that may carry the session token of GET requests instead of the Authorization header
+const sessionCookie = "session_token"
+
+// errMissingToken is the authentication error of a request that carries no session token
+var errMissingToken = errors.New("missing session token")
+
+type authContextKey struct{}
+
+// requestAuth is the outcome of authenticating a request, stored in its context by AuthMiddleware.
+type requestAuth struct {
+	token  string
+	userID int64
+	err    error
+}
+
+// maxRequestBodySize limits the body of any request, leaving room for the largest upload, a PGN file
+// (maxPGNUploadSize) sent as multipart form data.
+const maxRequestBodySize = maxPGNUploadSize + 1<<20
+
 // LoggingMiddleware logs the route and payload of every request
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var bodyBytes []byte
 		if r.Body != nil {
-			bodyBytes, _ = io.ReadAll(r.Body)
+			var err error
+			bodyBytes, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+			if err != nil {
+				utils.WriteJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body too large"})
+				return
+			}
 			// Restore the io.ReadCloser to its original state
 			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 		}
 		var logMessage string
-		if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		// The refresh token in the body of /api/refresh is a long-lived credential
+		if (r.Method == http.MethodPost || r.Method == http.MethodPut) && r.URL.Path != "/api/refresh" {
 			logMessage = "Payload: " + string(bodyBytes)
 		}
 		if len(r.URL.Path) < 6 || r.URL.Path[len(r.URL.Path)-6:] != "/board" {
@@ -32,11 +62,14 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*") // Allow requests from all origins
+		// Set CORS headers, credentials are only shared with the frontend origins
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); allowedOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID")
 
 		// Handle preflight requests
 		if r.Method == http.MethodOptions {
@@ -47,3 +80,94 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// AuthMiddleware authenticates every request once and stores the user in the request context, where
+// handlers read it with authenticatedUser. The session token is taken from, in order: the Authorization
+// header (Bearer <token>), the session_token cookie, the ?token= query parameter of WebSocket and
+// EventSource requests (browsers cannot set headers on those), and the player_token or user field of a
+// JSON body. Body tokens are deprecated; responses to such requests carry a Deprecation header.
+// The cookie only authenticates GET requests: browsers also send it with requests forged by other
+// sites, so state-changing requests must name their token explicitly.
+// Requests are passed on even when they are not authenticated, so public endpoints keep working.
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := &requestAuth{err: errMissingToken}
+		token, fromBody := findToken(r)
+		if token != "" {
+			dbConn, err := db.InitDB()
+			if err != nil {
+				utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+				return
+			}
+			auth.token = token
+			auth.userID, auth.err = db.GetUserIDBySessionToken(dbConn, token)
+			if fromBody {
+				w.Header().Set("Deprecation", "true")
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authContextKey{}, auth)))
+	})
+}
+
+// findToken returns the session token of a request and whether it was read from the deprecated body fields.
+func findToken(r *http.Request) (string, bool) {
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer "), false
+	}
+	if cookie, err := r.Cookie(sessionCookie); err == nil && cookie.Value != "" && isReadOnly(r) {
+		return cookie.Value, false
+	}
+	if isStreamRequest(r) {
+		return r.URL.Query().Get("token"), false
+	}
+	if r.Body == nil || r.Method == http.MethodGet {
+		return "", false
+	}
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	// Restore the body for the handler
+	r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return "", false
+	}
+	var body struct {
+		PlayerToken string `json:"player_token"`
+		User        string `json:"user"` // POST /api/games/move
+	}
+	if json.Unmarshal(bodyBytes, &body) != nil {
+		return "", false
+	}
+	if body.PlayerToken != "" {
+		return body.PlayerToken, true
+	}
+	return body.User, body.User != ""
+}
+
+// isReadOnly reports whether r is a request that does not change state.
+func isReadOnly(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// isStreamRequest reports whether r opens a WebSocket or an EventSource stream.
+func isStreamRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// authenticatedUser returns the user authenticated by AuthMiddleware, or why the request is not
+// authenticated (errMissingToken, or an error of db.GetUserIDBySessionToken), to pass to writeTokenError.
+func authenticatedUser(r *http.Request) (int64, error) {
+	auth, ok := r.Context().Value(authContextKey{}).(*requestAuth)
+	if !ok {
+		return 0, errMissingToken
+	}
+	return auth.userID, auth.err
+}
+
+// authToken returns the session token the request was made with, valid or not, or "" if there is none.
+func authToken(r *http.Request) string {
+	if auth, ok := r.Context().Value(authContextKey{}).(*requestAuth); ok {
+		return auth.token
+	}
+	return ""
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/movevalidation"
+	"gophermatebackend/internal/pgn"
+	"gophermatebackend/internal/utils"
+)
+
+// PGNHandler handles GET /api/games/{id}/pgn. Only the players of the game, or the user who
+// imported it, can export it.
+func PGNHandler(w http.ResponseWriter, r *http.Request) {
+	// Parse game ID from URL: /api/games/{id}/pgn
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid PGN URL"})
+		return
+	}
+	gameID := parts[3]
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	ok, err := db.CanViewGame(dbConn, gameID, userID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+	if !ok {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "User is not part of the game session"})
+		return
+	}
+
+	game, err := db.GetGame(dbConn, gameID)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("PGNHandler: Failed to get game: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get game"})
+		return
+	}
+
+	moves, err := db.GetMoves(dbConn, gameID)
+	if err != nil {
+		utils.LogError("PGNHandler: Failed to get moves: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get moves"})
+		return
+	}
+
+	pgnGame, err := buildPGN(game, moves)
+	if err != nil {
+		utils.LogError("PGNHandler: Failed to replay game " + gameID + ": " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to build PGN"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-chess-pgn")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+gameID+".pgn\"")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(pgn.Format(*pgnGame)))
+}
+
+// buildPGN replays the stored moves from the game's starting position to produce SAN move text,
+// so games recorded in the legacy notation are exported the same way as newer ones.
+func buildPGN(game *db.Game, moves []db.Move) (*pgn.Game, error) {
+	board, err := db.StartingBoard(game.InitialFEN.String)
+	if err != nil {
+		return nil, err
+	}
+
+	result := pgn.ResultFromWinner(game.Winner.String)
+	pgnGame := &pgn.Game{
+		Tags: []pgn.Tag{
+			{Name: "Event", Value: "GopherMate game"},
+			{Name: "Site", Value: "GopherMate"},
+			{Name: "Date", Value: game.CreatedAt.Format("2006.01.02")},
+			{Name: "Round", Value: "-"},
+			{Name: "White", Value: usernameOrUnknown(game.WhiteUsername)},
+			{Name: "Black", Value: usernameOrUnknown(game.BlackUsername)},
+			{Name: "Result", Value: result},
+		},
+		Result: result,
+	}
+	if game.InitialFEN.Valid {
+		pgnGame.Tags = append(pgnGame.Tags, pgn.Tag{Name: "SetUp", Value: "1"}, pgn.Tag{Name: "FEN", Value: game.InitialFEN.String})
+	}
+
+	err = db.ReplayMoves(board, moves, func(m db.ReplayedMove) error {
+		pgnGame.Moves = append(pgnGame.Moves, m.SAN)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pgnGame, nil
+}
+
+// Limits of a PGN upload accepted by ImportPGNHandler. Every ply is validated against the legal moves
+// of its position, so the plies of all games together are capped as well as the file size.
+const (
+	maxPGNUploadSize = 5 << 20
+	maxPGNGames      = 50
+	maxPGNPlies      = 5000
+)
+
+// pgnImportError reports an invalid move in an uploaded PGN file. Game and ply are 1-based.
+type pgnImportError struct {
+	Game  int    `json:"game"`
+	Ply   int    `json:"ply"`
+	Move  string `json:"move"`
+	Error string `json:"error"`
+}
+
+// ImportPGNHandler handles POST /api/games/import. The PGN is sent as the raw request body or as
+// a multipart "file" field and may contain several games, up to maxPGNGames and maxPGNPlies.
+// Every game is replayed to verify its moves; if any game is invalid nothing is stored and the
+// errors are returned with their game index and ply.
+func ImportPGNHandler(w http.ResponseWriter, r *http.Request) {
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPGNUploadSize)
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Missing PGN file"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	text, err := io.ReadAll(body)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Failed to read PGN"})
+		return
+	}
+
+	games, err := pgn.Parse(string(text))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid PGN: " + err.Error()})
+		return
+	}
+	if len(games) == 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "No games found in PGN"})
+		return
+	}
+	plies := 0
+	for _, g := range games {
+		plies += len(g.Moves)
+	}
+	if len(games) > maxPGNGames || plies > maxPGNPlies {
+		utils.WriteJSON(w, http.StatusRequestEntityTooLarge, map[string]string{
+			"error": fmt.Sprintf("A PGN import is limited to %d games and %d half-moves in total", maxPGNGames, maxPGNPlies),
+		})
+		return
+	}
+
+	var imported []db.ImportedGame
+	var importErrors []pgnImportError
+	for i, g := range games {
+		game, importErr := replayPGN(g)
+		if importErr != nil {
+			importErr.Game = i + 1
+			importErrors = append(importErrors, *importErr)
+			continue
+		}
+		game.ImportedBy = userID
+		imported = append(imported, *game)
+	}
+	if len(importErrors) > 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "Invalid moves in PGN", "errors": importErrors})
+		return
+	}
+
+	ids := make([]string, 0, len(imported))
+	for _, game := range imported {
+		id, err := db.CreateImportedGame(dbConn, game)
+		if err != nil {
+			utils.LogError("ImportPGNHandler: Failed to store game: " + err.Error())
+			utils.WriteJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Failed to store games", "ids": ids})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{"message": "Games imported successfully", "ids": ids})
+}
+
+// replayPGN replays a parsed PGN game from its starting position and converts it to an ImportedGame.
+func replayPGN(g pgn.Game) (*db.ImportedGame, *pgnImportError) {
+	fen := g.Tag("FEN")
+	board, err := db.StartingBoard(fen)
+	if err != nil {
+		return nil, &pgnImportError{Error: "Invalid FEN tag: " + err.Error()}
+	}
+
+	game := &db.ImportedGame{
+		WhiteName:  g.Tag("White"),
+		BlackName:  g.Tag("Black"),
+		Winner:     pgn.WinnerFromResult(g.Result),
+		PlayedAt:   time.Now(),
+		InitialFEN: fen,
+	}
+	// PGN dates may contain unknown parts such as "2024.??.??", which are kept as the import time
+	if playedAt, err := time.Parse("2006.01.02", g.Tag("Date")); err == nil {
+		game.PlayedAt = playedAt
+	}
+
+	moves := make([]db.Move, len(g.Moves))
+	for i, text := range g.Moves {
+		moves[i] = db.Move{Notation: text}
+	}
+	err = db.ReplayMoves(board, moves, func(m db.ReplayedMove) error {
+		game.Moves = append(game.Moves, db.Move{Notation: m.SAN, UCI: movevalidation.UCI(m.Move)})
+		return nil
+	})
+	var replayErr *db.ReplayError
+	if errors.As(err, &replayErr) {
+		return nil, &pgnImportError{Ply: replayErr.Ply, Move: replayErr.Notation, Error: replayErr.Err.Error()}
+	}
+	return game, nil
+}
+
+// usernameOrUnknown returns the username, or "?" as PGN expects for an unknown player.
+func usernameOrUnknown(name sql.NullString) string {
+	if !name.Valid || name.String == "" {
+		return "?"
+	}
+	return name.String
+}
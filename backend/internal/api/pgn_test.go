@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+
+	"gophermatebackend/internal/pgn"
+)
+
+func TestReplayPGNCastlingWithZeros(t *testing.T) {
+	text := `[Event "Club game"]
+[White "A"]
+[Black "B"]
+[Result "*"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. 0-0 d6 5. d3 Be6 6. Nc3 Qd7 7. a3 0-0-0 *
+`
+	games, err := pgn.Parse(text)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("got %d games, want 1", len(games))
+	}
+	if got := games[0].Moves[6]; got != "0-0" {
+		t.Fatalf("move 7 = %q, want 0-0", got)
+	}
+
+	game, importErr := replayPGN(games[0])
+	if importErr != nil {
+		t.Fatalf("replayPGN: ply %d %q: %s", importErr.Ply, importErr.Move, importErr.Error)
+	}
+	if len(game.Moves) != 14 {
+		t.Fatalf("got %d moves, want 14", len(game.Moves))
+	}
+	for ply, want := range map[int]string{6: "O-O", 13: "O-O-O"} {
+		if got := game.Moves[ply].Notation; got != want {
+			t.Errorf("ply %d = %q, want %q", ply+1, got, want)
+		}
+	}
+	if got := game.Moves[6].UCI; got != "e1g1" {
+		t.Errorf("white castling UCI = %q, want e1g1", got)
+	}
+	if got := game.Moves[13].UCI; got != "e8c8" {
+		t.Errorf("black castling UCI = %q, want e8c8", got)
+	}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/utils"
+)
+
+// recentGamesLimit is the number of games shown on a profile
+const recentGamesLimit = 10
+
+// UsersHandler handles GET /api/users/{username}, the public profile of a player, and
+// GET /api/users/{username}/games, their finished games, most recent first, a page at a time.
+// The games can be filtered with result=win|loss|draw|none, color=white|black, opponent=<username>
+// and from/to (YYYY-MM-DD, both inclusive), and paged with limit and cursor.
+func UsersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || len(parts) > 5 || parts[3] == "" || (len(parts) == 5 && parts[4] != "games") {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Not found"})
+		return
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	profile, err := db.GetProfile(dbConn, parts[3])
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("UsersHandler: Failed to get profile: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+		return
+	}
+
+	if len(parts) == 4 {
+		writeProfile(w, dbConn, profile, false)
+		return
+	}
+
+	filter, err := historyFilter(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	games, nextCursor, err := db.GetUserGames(dbConn, profile.ID, filter)
+	if err == db.ErrInvalidCursor {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		utils.LogError("UsersHandler: Failed to get games: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get games"})
+		return
+	}
+
+	var next interface{}
+	if nextCursor != "" {
+		next = nextCursor
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{"games": historyResponse(games), "next_cursor": next})
+}
+
+// writeProfile writes a profile with the user's ratings and recent games. The email is only
+// included in the user's own profile.
+func writeProfile(w http.ResponseWriter, dbConn *sql.DB, profile *db.Profile, own bool) {
+	ratings, err := db.GetUserRatings(dbConn, profile.ID)
+	if err != nil {
+		utils.LogError("writeProfile: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get ratings"})
+		return
+	}
+	recent, _, err := db.GetUserGames(dbConn, profile.ID, db.HistoryFilter{Limit: recentGamesLimit})
+	if err != nil {
+		utils.LogError("writeProfile: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get games"})
+		return
+	}
+
+	ratingsResp := map[string]interface{}{}
+	for _, r := range ratings {
+		ratingsResp[r.Category] = map[string]interface{}{
+			"rating":      r.Value(),
+			"deviation":   int(math.Round(r.Rating.Deviation)),
+			"provisional": r.Rating.Provisional(),
+			"games":       r.GamesPlayed,
+		}
+	}
+
+	resp := map[string]interface{}{
+		"id":         profile.ID,
+		"username":   profile.Username,
+		"created_at": profile.CreatedAt.Format(time.RFC3339),
+		"ratings":    ratingsResp,
+		"stats": map[string]int{
+			"games":  profile.Wins + profile.Losses + profile.Draws,
+			"wins":   profile.Wins,
+			"losses": profile.Losses,
+			"draws":  profile.Draws,
+		},
+		"recent_games": historyResponse(recent),
+	}
+	if own {
+		resp["email"] = profile.Email
+	}
+	utils.WriteJSON(w, http.StatusOK, resp)
+}
+
+// historyFilter reads the query parameters of GET /api/users/{username}/games.
+func historyFilter(r *http.Request) (db.HistoryFilter, error) {
+	query := r.URL.Query()
+	filter := db.HistoryFilter{
+		Result:   query.Get("result"),
+		Color:    query.Get("color"),
+		Opponent: query.Get("opponent"),
+		Cursor:   query.Get("cursor"),
+	}
+
+	switch filter.Result {
+	case "", "win", "loss", "draw", "none":
+	default:
+		return filter, errors.New("Invalid result, expected win, loss, draw or none")
+	}
+	switch filter.Color {
+	case "", "white", "black":
+	default:
+		return filter, errors.New("Invalid color, expected white or black")
+	}
+
+	var err error
+	if filter.From, err = historyDate(query.Get("from"), false); err != nil {
+		return filter, errors.New("Invalid from date")
+	}
+	if filter.To, err = historyDate(query.Get("to"), true); err != nil {
+		return filter, errors.New("Invalid to date")
+	}
+	if filter.Limit, err = pageLimit(r); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// historyDate parses a YYYY-MM-DD date range bound. The upper bound includes the whole day.
+func historyDate(s string, upper bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func historyResponse(games []db.HistoryGame) []map[string]interface{} {
+	resp := make([]map[string]interface{}, len(games))
+	for i, game := range games {
+		opponentID, opponentName := game.PlayerBlack, game.BlackUsername
+		if game.Color == "black" {
+			opponentID, opponentName = game.PlayerWhite, game.WhiteUsername
+		}
+		var opponent interface{}
+		if opponentID.Valid {
+			opponent = map[string]interface{}{"id": opponentID.Int64, "username": opponentName.String}
+		}
+
+		// Games finished without a recorded winner count as neither a win nor a loss
+		result := "loss"
+		switch game.Winner.String {
+		case game.Color:
+			result = "win"
+		case "draw":
+			result = "draw"
+		case "":
+			result = "none"
+		}
+
+		resp[i] = map[string]interface{}{
+			"id":           game.ID,
+			"color":        game.Color,
+			"opponent":     opponent,
+			"result":       result,
+			"reason":       game.ResultReason.String,
+			"time_control": game.TimeControl.String,
+			"rated":        game.Rated,
+			"custom_start": game.InitialFEN.Valid,
+			"moves":        (game.PlyCount + 1) / 2,
+			"created_at":   game.CreatedAt.Format(time.RFC3339),
+			"finished_at":  game.FinishedAt.Time.Format(time.RFC3339),
+		}
+		if game.RatingBefore.Valid && game.RatingAfter.Valid {
+			resp[i]["rating_before"] = int(math.Round(game.RatingBefore.Float64))
+			resp[i]["rating_after"] = int(math.Round(game.RatingAfter.Float64))
+		}
+	}
+	return resp
+}
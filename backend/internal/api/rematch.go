@@ -0,0 +1,123 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/events"
+	"gophermatebackend/internal/utils"
+)
+
+// OfferRematchHandler handles POST /api/games/:id/offer-rematch
+func OfferRematchHandler(w http.ResponseWriter, r *http.Request) {
+	dbConn, gameID, color, ok := rematchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := db.OfferRematch(dbConn, gameID, color); err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "A rematch cannot be offered for this game"})
+			return
+		}
+		utils.LogError("OfferRematchHandler: Failed to offer rematch: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to offer rematch"})
+		return
+	}
+	events.Publish(gameID, events.Event{Type: events.TypeRematchOffer, Data: map[string]interface{}{"offered_by": color}})
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Rematch offer sent"})
+}
+
+// AcceptRematchHandler handles POST /api/games/:id/accept-rematch
+func AcceptRematchHandler(w http.ResponseWriter, r *http.Request) {
+	dbConn, gameID, color, ok := rematchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rematchID, timeControl, err := db.AcceptRematch(dbConn, gameID, color)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "No rematch offer to accept"})
+			return
+		}
+		utils.LogError("AcceptRematchHandler: Failed to accept rematch: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to accept rematch"})
+		return
+	}
+
+	board := cache.NewInitialBoard()
+	board.Clock = cache.NewClock(timeControl)
+	cache.SetBoard(rematchID, board)
+	events.Publish(gameID, events.Event{Type: events.TypeRematchAccepted, Data: map[string]interface{}{"game_id": rematchID}})
+
+	// Colors are swapped in the rematch
+	newColor := "white"
+	if color == "white" {
+		newColor = "black"
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Rematch accepted", "game_id": rematchID, "color": newColor})
+}
+
+// DeclineRematchHandler handles POST /api/games/:id/decline-rematch
+func DeclineRematchHandler(w http.ResponseWriter, r *http.Request) {
+	dbConn, gameID, color, ok := rematchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := db.DeclineRematch(dbConn, gameID, color); err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "No rematch offer to decline"})
+			return
+		}
+		utils.LogError("DeclineRematchHandler: Failed to decline rematch: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to decline rematch"})
+		return
+	}
+	events.Publish(gameID, events.Event{Type: events.TypeRematchDeclined, Data: map[string]interface{}{"declined_by": color}})
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Rematch offer declined"})
+}
+
+// rematchRequest parses the game ID from /api/games/{id}/... and returns the color of the
+// authenticated player.
+func rematchRequest(w http.ResponseWriter, r *http.Request) (*sql.DB, string, string, bool) {
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return nil, "", "", false
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid rematch URL"})
+		return nil, "", "", false
+	}
+	gameID := parts[3]
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return nil, "", "", false
+	}
+
+	color, err := db.GetUserColorInGame(dbConn, gameID, userID)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
+		return nil, "", "", false
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to determine player color"})
+		return nil, "", "", false
+	}
+	if color == "" {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "User is not a player in this game"})
+		return nil, "", "", false
+	}
+	return dbConn, gameID, color, true
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/utils"
+)
+
+// SessionsHandler handles GET /api/sessions, which lists the active sessions of the user,
+// and DELETE /api/sessions/{id}, which revokes one of them.
+func SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/api/sessions" && r.Method == http.MethodGet:
+		listSessions(w, dbConn, userID, authToken(r))
+	case strings.HasPrefix(path, "/api/sessions/") && r.Method == http.MethodDelete:
+		revokeSession(w, dbConn, userID, strings.TrimPrefix(path, "/api/sessions/"))
+	default:
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	}
+}
+
+func listSessions(w http.ResponseWriter, dbConn *sql.DB, userID int64, token string) {
+	sessions, err := db.GetUserSessions(dbConn, userID, token)
+	if err != nil {
+		utils.LogError("listSessions: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get sessions"})
+		return
+	}
+
+	response := make([]map[string]interface{}, len(sessions))
+	for i, s := range sessions {
+		response[i] = map[string]interface{}{
+			"id":           s.ID,
+			"user_agent":   s.UserAgent,
+			"ip_address":   s.IPAddress,
+			"created_at":   s.CreatedAt.Format(time.RFC3339),
+			"last_seen_at": s.LastSeenAt.Format(time.RFC3339),
+			"expires_at":   s.ExpiresAt.Format(time.RFC3339),
+			"current":      s.Current,
+		}
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{"sessions": response})
+}
+
+func revokeSession(w http.ResponseWriter, dbConn *sql.DB, userID int64, idParam string) {
+	sessionID, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid session ID"})
+		return
+	}
+
+	err = db.RevokeSessionByID(dbConn, userID, sessionID)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("revokeSession: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to revoke session"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
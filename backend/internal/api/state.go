@@ -0,0 +1,170 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/movevalidation"
+	"gophermatebackend/internal/utils"
+)
+
+type statePosition struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+type stateMove struct {
+	Piece     string        `json:"piece"`
+	From      statePosition `json:"from"`
+	To        statePosition `json:"to"`
+	Promotion string        `json:"promotion,omitempty"`
+	UCI       string        `json:"uci"`
+}
+
+// GameStateHandler handles GET /api/games/{id}/state. It returns everything a client needs to render
+// the game after a reload: the full board, side to move, castling and en passant state, check flag,
+// game status, both players and the legal moves of the side to move. Players of a private game that is
+// still waiting for an opponent also get its invite code.
+func GameStateHandler(w http.ResponseWriter, r *http.Request) {
+	// Parse game ID from URL: /api/games/{id}/state
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid state URL"})
+		return
+	}
+	gameID := parts[3]
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	ok, err := db.CanViewGame(dbConn, gameID, userID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+	if !ok {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "User is not part of the game session"})
+		return
+	}
+
+	game, err := db.GetGame(dbConn, gameID)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Game not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("GameStateHandler: Failed to get game: " + err.Error())
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get game"})
+		return
+	}
+
+	// Running games use the cached board, finished games are replayed to show the final position
+	var board *cache.Board
+	if game.FinishedAt.Valid {
+		board, err = db.BuildBoard(dbConn, game)
+	} else {
+		board, err = db.LoadBoardCopy(dbConn, gameID)
+		if err == nil && board == nil {
+			// Finished meanwhile, its board is no longer cached
+			board, err = db.BuildBoard(dbConn, game)
+		}
+	}
+	if errors.Is(err, db.ErrUnreplayable) {
+		utils.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Game can no longer be replayed"})
+		return
+	}
+	if err != nil || board == nil {
+		utils.LogError("GameStateHandler: Failed to load board for game " + gameID)
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load game"})
+		return
+	}
+
+	status := "in_progress"
+	switch {
+	case game.FinishedAt.Valid:
+		status = "finished"
+	case !game.PlayerWhite.Valid || !game.PlayerBlack.Valid:
+		status = "waiting"
+	}
+
+	sideToMove := movevalidation.SideToMove(board)
+	legalMoves := []stateMove{}
+	if status != "finished" {
+		for _, m := range movevalidation.LegalMoves(board) {
+			legalMoves = append(legalMoves, stateMove{
+				Piece:     m.Piece,
+				From:      statePosition{Row: m.From.Row, Col: m.From.Col},
+				To:        statePosition{Row: m.To.Row, Col: m.To.Col},
+				Promotion: m.Promotion,
+				UCI:       movevalidation.UCI(m),
+			})
+		}
+	}
+
+	yourColor := "white"
+	if game.PlayerBlack.Valid && game.PlayerBlack.Int64 == userID {
+		yourColor = "black"
+	}
+
+	resp := map[string]interface{}{
+		"id":           game.ID,
+		"status":       status,
+		"white":        game.WhiteUsername.String,
+		"black":        game.BlackUsername.String,
+		"your_color":   yourColor,
+		"rated":        game.Rated,
+		"squares":      board.Squares,
+		"side_to_move": sideToMove,
+		"castling": map[string]bool{
+			"white_king_side":  board.Castling.WhiteKingSide,
+			"white_queen_side": board.Castling.WhiteQueenSide,
+			"black_king_side":  board.Castling.BlackKingSide,
+			"black_queen_side": board.Castling.BlackQueenSide,
+		},
+		"en_passant":         board.EnPassant,
+		"check":              movevalidation.IsInCheck(board, sideToMove),
+		"fen":                cache.ToFEN(board),
+		"last_move_number":   board.LastMoveNumber,
+		"last_move_notation": board.LastMoveNotation,
+		"legal_moves":        legalMoves,
+	}
+	if clock := clockState(board, !game.FinishedAt.Valid); clock != nil {
+		resp["clock"] = clock
+	}
+	if board.DrawOfferPending {
+		resp["draw_offer"] = board.DrawOffer
+	}
+	if game.FinishedAt.Valid {
+		resp["winner"] = game.Winner.String
+		resp["reason"] = game.ResultReason.String
+	}
+	// The creator of a private game can share its invite code again while the other seat is empty
+	if game.InviteCode.Valid && status == "waiting" &&
+		(game.PlayerWhite.Int64 == userID || game.PlayerBlack.Int64 == userID) {
+		resp["invite_code"] = game.InviteCode.String
+	}
+	if game.RematchOffer.Valid {
+		resp["rematch_offer"] = game.RematchOffer.String
+	}
+	if game.RematchGameID.Valid {
+		resp["rematch_game_id"] = game.RematchGameID.String
+	}
+	if game.RematchOf.Valid {
+		resp["rematch_of"] = game.RematchOf.String
+	}
+
+	utils.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gophermatebackend/internal/db"
+	"gophermatebackend/internal/events"
+	"gophermatebackend/internal/utils"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	websocketPingInterval = 30 * time.Second
+	websocketWriteTimeout = 10 * time.Second
+	websocketMaxMessage   = 4096
+)
+
+// apiConfig is read once, the allowed origins do not change while the server runs.
+var apiConfig = sync.OnceValue(utils.LoadConfig)
+
+// allowedOrigin reports whether a browser request comes from one of the frontend origins (ALLOWED_ORIGINS).
+func allowedOrigin(origin string) bool {
+	for _, allowed := range apiConfig().AllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// GameWebSocketHandler handles GET /api/games/{id}/ws. After the upgrade the server pushes the game's
+// events (moves, draw offers and responses, resignations, game end) as JSON text messages.
+// Browsers send the session cookie with WebSocket requests from any site, so the Origin must be one
+// of the frontend origins (ALLOWED_ORIGINS).
+func GameWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	// Parse game ID from URL: /api/games/{id}/ws
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid websocket URL"})
+		return
+	}
+	gameID := parts[3]
+
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Expected a WebSocket upgrade request"})
+		return
+	}
+	if !allowedOrigin(r.Header.Get("Origin")) {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "Origin not allowed"})
+		return
+	}
+
+	dbConn, err := db.InitDB()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	userID, err := authenticatedUser(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	ok, err := db.CanViewGame(dbConn, gameID, userID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+	if !ok {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "User is not part of the game session"})
+		return
+	}
+
+	// Subscribe before the handshake so no event published after the upgrade is missed
+	ch, unsubscribe := events.Subscribe(gameID)
+	defer unsubscribe()
+
+	server := websocket.Server{
+		// The origin has been checked above
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			streamGameEvents(ws, ch)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// streamGameEvents writes the events of ch to the connection until the client goes away. The library
+// answers the client's pings and close frames; client data messages are ignored.
+func streamGameEvents(ws *websocket.Conn, ch <-chan events.Event) {
+	ws.MaxPayloadBytes = websocketMaxMessage
+	closed := make(chan struct{})
+	go func() {
+		var message []byte
+		for websocket.Message.Receive(ws, &message) == nil {
+		}
+		close(closed)
+	}()
+
+	ticker := time.NewTicker(websocketPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-ch:
+			payload, err := json.Marshal(event)
+			if err != nil {
+				utils.LogError("GameWebSocketHandler: Failed to encode event: " + err.Error())
+				continue
+			}
+			ws.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+			if err := websocket.Message.Send(ws, string(payload)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			// Pings keep proxies from closing an idle connection and detect clients that went away
+			ws.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+			ws.PayloadType = websocket.PingFrame
+			_, err := ws.Write(nil)
+			ws.PayloadType = websocket.TextFrame
+			if err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
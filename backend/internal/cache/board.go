@@ -7,12 +7,26 @@ import (
 
 // Board represents the state of a chess game in memory.
 type Board struct {
-	Squares          [8][8]string // Each square holds a piece string (e.g., "white-pawn", "black-king", or "")
-	LastMove         string       // "white" or "black" (whose turn just played)
-	LastMoveNumber   int          // The move number of the last move made
-	LastMoveNotation string       // The notation of the last move made
-	DrawOffer        string       // "white", "black", or "" (who offered draw, empty if none)
-	DrawOfferPending bool         // true if a draw offer is pending, false otherwise
+	Squares          [8][8]string   // Each square holds a piece string (e.g., "white-pawn", "black-king", or "")
+	LastMove         string         // "white" or "black" (whose turn just played)
+	LastMoveNumber   int            // The move number of the last move made
+	LastMoveNotation string         // The notation of the last move made
+	DrawOffer        string         // "white", "black", or "" (who offered draw, empty if none)
+	DrawOfferPending bool           // true if a draw offer is pending, false otherwise
+	Castling         CastlingRights // Castling moves still available to each side
+	EnPassant        string         // Square behind a pawn that just moved two squares (e.g., "e3"), "" if none
+	HalfmoveClock    int            // Plies since the last capture or pawn move
+	FullmoveNumber   int            // Starts at 1 and is incremented after each black move
+	Clock            Clock          // Time control and remaining time of both players, zero for untimed games
+}
+
+// CastlingRights tracks which castling moves are still available. A right is lost once the king
+// or the corresponding rook moves, or the rook is captured on its starting square.
+type CastlingRights struct {
+	WhiteKingSide  bool
+	WhiteQueenSide bool
+	BlackKingSide  bool
+	BlackQueenSide bool
 }
 
 // boardCache is the in-memory map of session string to Board pointer and its last updated time.
@@ -40,7 +54,7 @@ func GetBoard(session string) *Board {
 	return entry.Board
 }
 
-// SetBoard sets or updates the board for a session string.
+// SetBoard sets or updates the board for a session string and wakes up waiters on BoardChanged.
 func SetBoard(session string, board *Board) {
 	boardCacheMu.Lock()
 	boardCache[session] = &boardCacheEntry{
@@ -48,25 +62,32 @@ func SetBoard(session string, board *Board) {
 		UpdatedAt: time.Now(),
 	}
 	boardCacheMu.Unlock()
+	notifyBoardChanged(session)
 }
 
-// CleanExpiredBoards removes boards that have not been updated in the last 30 minutes.
+// CleanExpiredBoards removes boards that have not been updated in the last 30 minutes, and wakes up
+// waiters on boards that are no longer cached so their entries do not pile up.
 func CleanExpiredBoards() {
 	boardCacheMu.Lock()
 	now := time.Now()
+	cached := make(map[string]bool, len(boardCache))
 	for session, entry := range boardCache {
 		if now.Sub(entry.UpdatedAt) > 30*time.Minute {
 			delete(boardCache, session)
+			continue
 		}
+		cached[session] = true
 	}
 	boardCacheMu.Unlock()
+	pruneBoardWaiters(cached)
 }
 
-// ClearBoard removes a specific board from the cache.
+// ClearBoard removes a specific board from the cache and wakes up waiters on BoardChanged.
 func ClearBoard(session string) {
 	boardCacheMu.Lock()
 	delete(boardCache, session)
 	boardCacheMu.Unlock()
+	notifyBoardChanged(session)
 }
 
 // NewInitialBoard returns a new Board with the standard chess starting position and last move as "black" (so white moves first).
@@ -99,5 +120,7 @@ func NewInitialBoard() *Board {
 	b.LastMove = "black"    // So white moves first
 	b.LastMoveNumber = 0    // No moves made yet
 	b.LastMoveNotation = "" // No moves made yet
+	b.Castling = CastlingRights{WhiteKingSide: true, WhiteQueenSide: true, BlackKingSide: true, BlackQueenSide: true}
+	b.FullmoveNumber = 1
 	return &b
 }
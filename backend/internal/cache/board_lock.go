@@ -0,0 +1,58 @@
+package cache
+
+import "sync"
+
+// boardLock is the mutex serializing changes to a board, with the number of callers holding or
+// waiting for it so it can be dropped once nobody needs it.
+type boardLock struct {
+	mu    sync.Mutex
+	users int
+}
+
+// boardLocks maps a session string to the lock of its board while it is in use.
+var (
+	boardLocks   = make(map[string]*boardLock)
+	boardLocksMu sync.Mutex
+)
+
+// LockBoard locks the board of the session until the returned function is called. Handlers that
+// change a board hold the lock from loading it until the change is saved and applied, so concurrent
+// changes to the same game are applied one at a time.
+func LockBoard(session string) func() {
+	boardLocksMu.Lock()
+	lock, ok := boardLocks[session]
+	if !ok {
+		lock = &boardLock{}
+		boardLocks[session] = lock
+	}
+	lock.users++
+	boardLocksMu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		boardLocksMu.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(boardLocks, session)
+		}
+		boardLocksMu.Unlock()
+	}
+}
+
+// GetBoardCopy returns a copy of the cached board of the session, taken under its lock, or nil if
+// it is not cached. Readers use it so they never see a board in the middle of a change.
+func GetBoardCopy(session string) *Board {
+	unlock := LockBoard(session)
+	defer unlock()
+	if board := GetBoard(session); board != nil {
+		return board.Copy()
+	}
+	return nil
+}
+
+// Copy returns a copy of the board that can be read while the original keeps changing.
+func (b *Board) Copy() *Board {
+	c := *b
+	return &c
+}
@@ -0,0 +1,45 @@
+package cache
+
+import "sync"
+
+// boardWaiters maps a session string to a channel closed on the next change of its board.
+var (
+	boardWaiters   = make(map[string]chan struct{})
+	boardWaitersMu sync.Mutex
+)
+
+// BoardChanged returns a channel that is closed the next time the board of the session is set or cleared.
+// Callers get the channel before reading the board, so a change between the read and the wait is not missed.
+func BoardChanged(session string) <-chan struct{} {
+	boardWaitersMu.Lock()
+	defer boardWaitersMu.Unlock()
+	ch, ok := boardWaiters[session]
+	if !ok {
+		ch = make(chan struct{})
+		boardWaiters[session] = ch
+	}
+	return ch
+}
+
+// notifyBoardChanged wakes everyone waiting on the board of the session.
+func notifyBoardChanged(session string) {
+	boardWaitersMu.Lock()
+	if ch, ok := boardWaiters[session]; ok {
+		close(ch)
+		delete(boardWaiters, session)
+	}
+	boardWaitersMu.Unlock()
+}
+
+// pruneBoardWaiters wakes everyone waiting on a board that is not in cached. Waiters re-check the
+// board when woken, so this only drops the entries of boards that are gone or were never cached.
+func pruneBoardWaiters(cached map[string]bool) {
+	boardWaitersMu.Lock()
+	for session, ch := range boardWaiters {
+		if !cached[session] {
+			close(ch)
+			delete(boardWaiters, session)
+		}
+	}
+	boardWaitersMu.Unlock()
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeControl is the time limit of a game: a base time per player plus an increment added after
+// every move (e.g., 5+3), or a number of days per move for correspondence games.
+// The zero value means the game is untimed.
+type TimeControl struct {
+	Base        time.Duration
+	Increment   time.Duration
+	DaysPerMove int
+}
+
+// clockTimeControlPattern matches "<minutes>+<seconds>", minutes being a whole or plain decimal number.
+var clockTimeControlPattern = regexp.MustCompile(`^(\d{1,3}(?:\.\d{1,2})?)\+(\d{1,3})$`)
+
+// minBaseMinutes is the shortest base time of a clock game, 15 seconds.
+const minBaseMinutes = 0.25
+
+// ParseTimeControl reads a time control written as "<minutes>+<seconds>" (e.g., "5+3", "10+0")
+// or "<days>d" for correspondence (e.g., "3d"). An empty string is an untimed game.
+func ParseTimeControl(s string) (TimeControl, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return TimeControl{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 || n > 14 {
+			return TimeControl{}, errors.New("days per move must be between 1 and 14")
+		}
+		return TimeControl{DaysPerMove: n}, nil
+	}
+	m := clockTimeControlPattern.FindStringSubmatch(s)
+	if m == nil {
+		return TimeControl{}, errors.New("time control must look like 5+3, 0.5+0 or 3d")
+	}
+	base, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || base < minBaseMinutes || base > 180 {
+		return TimeControl{}, errors.New("base time must be between 0.25 and 180 minutes")
+	}
+	increment, err := strconv.Atoi(m[2])
+	if err != nil || increment > 180 {
+		return TimeControl{}, errors.New("increment must be between 0 and 180 seconds")
+	}
+	return TimeControl{
+		Base:      time.Duration(base * float64(time.Minute)),
+		Increment: time.Duration(increment) * time.Second,
+	}, nil
+}
+
+// String returns the time control in the form accepted by ParseTimeControl, or "" if untimed.
+func (tc TimeControl) String() string {
+	switch {
+	case tc.DaysPerMove > 0:
+		return fmt.Sprintf("%dd", tc.DaysPerMove)
+	case tc.IsTimed():
+		return strconv.FormatFloat(tc.Base.Minutes(), 'f', -1, 64) + "+" + strconv.Itoa(int(tc.Increment/time.Second))
+	}
+	return ""
+}
+
+// IsTimed reports whether the game has a running clock (correspondence games have a deadline instead).
+func (tc TimeControl) IsTimed() bool {
+	return tc.Base > 0
+}
+
+// Category returns the rating pool of the time control: "bullet", "blitz", "rapid", "classical" or
+// "correspondence". Clock games are sorted by their estimated duration, base time plus 40 increments.
+// Untimed games have no rating pool and return "".
+func (tc TimeControl) Category() string {
+	if tc.DaysPerMove > 0 {
+		return "correspondence"
+	}
+	if !tc.IsTimed() {
+		return ""
+	}
+	estimated := tc.Base + 40*tc.Increment
+	switch {
+	case estimated < 3*time.Minute:
+		return "bullet"
+	case estimated < 8*time.Minute:
+		return "blitz"
+	case estimated < 25*time.Minute:
+		return "rapid"
+	}
+	return "classical"
+}
+
+// Clock holds the remaining time of both players. TurnStartedAt is when the side to move started
+// thinking; it stays zero until the first move is made, so nobody loses time before the game starts.
+type Clock struct {
+	TimeControl    TimeControl
+	WhiteRemaining time.Duration
+	BlackRemaining time.Duration
+	TurnStartedAt  time.Time
+}
+
+// NewClock returns a stopped clock with the full base time for both players.
+func NewClock(tc TimeControl) Clock {
+	return Clock{TimeControl: tc, WhiteRemaining: tc.Base, BlackRemaining: tc.Base}
+}
+
+// Running reports whether time is being counted for the side to move.
+func (c *Clock) Running() bool {
+	return (c.TimeControl.IsTimed() || c.TimeControl.DaysPerMove > 0) && !c.TurnStartedAt.IsZero()
+}
+
+// Remaining returns the time left for color at now, counting the running turn of the side to move.
+func (c *Clock) Remaining(color string, sideToMove string, now time.Time) time.Duration {
+	remaining := c.stored(color)
+	if c.TimeControl.IsTimed() && c.Running() && color == sideToMove {
+		remaining -= now.Sub(c.TurnStartedAt)
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// stored returns the remaining time of color as of the start of the current turn.
+func (c *Clock) stored(color string) time.Duration {
+	if color == "black" {
+		return c.BlackRemaining
+	}
+	return c.WhiteRemaining
+}
+
+// Deadline returns when the side to move runs out of time, or the zero time if the clock is not running.
+func (c *Clock) Deadline(sideToMove string) time.Time {
+	if !c.Running() {
+		return time.Time{}
+	}
+	if c.TimeControl.DaysPerMove > 0 {
+		return c.TurnStartedAt.Add(time.Duration(c.TimeControl.DaysPerMove) * 24 * time.Hour)
+	}
+	return c.TurnStartedAt.Add(c.stored(sideToMove))
+}
+
+// Flagged reports whether the side to move has run out of time at now.
+func (c *Clock) Flagged(sideToMove string, now time.Time) bool {
+	return c.Running() && !now.Before(c.Deadline(sideToMove))
+}
+
+// Punch records a move by color at now: the time spent is deducted, the increment is added and
+// the opponent's turn starts. The first move of the game only starts the clock.
+func (c *Clock) Punch(color string, now time.Time) {
+	if c.TimeControl.IsTimed() && c.Running() {
+		spent := now.Sub(c.TurnStartedAt)
+		if color == "black" {
+			c.BlackRemaining += c.TimeControl.Increment - spent
+		} else {
+			c.WhiteRemaining += c.TimeControl.Increment - spent
+		}
+	}
+	if c.TimeControl.IsTimed() || c.TimeControl.DaysPerMove > 0 {
+		c.TurnStartedAt = now
+	}
+}
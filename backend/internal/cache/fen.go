@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartingFEN is the standard chess starting position.
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+var fenPieces = map[rune]string{
+	'P': "white-pawn", 'N': "white-knight", 'B': "white-bishop", 'R': "white-rook", 'Q': "white-queen", 'K': "white-king",
+	'p': "black-pawn", 'n': "black-knight", 'b': "black-bishop", 'r': "black-rook", 'q': "black-queen", 'k': "black-king",
+}
+
+// NewBoardFromFEN parses a FEN string into a Board. Side to move is stored through LastMove,
+// so "w" means the last move was made by black.
+func NewBoardFromFEN(fen string) (*Board, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		return nil, errors.New("FEN must have 6 fields")
+	}
+	var b Board
+
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, errors.New("FEN board must have 8 ranks")
+	}
+	kings := map[string]int{}
+	for row, rank := range ranks {
+		col := 0
+		for _, ch := range rank {
+			if ch >= '1' && ch <= '8' {
+				col += int(ch - '0')
+				continue
+			}
+			piece, ok := fenPieces[ch]
+			if !ok {
+				return nil, fmt.Errorf("invalid FEN piece %q", ch)
+			}
+			if col > 7 {
+				return nil, fmt.Errorf("FEN rank %d is too long", 8-row)
+			}
+			if (ch == 'P' || ch == 'p') && (row == 0 || row == 7) {
+				return nil, errors.New("FEN pawns cannot stand on the first or eighth rank")
+			}
+			b.Squares[row][col] = piece
+			if ch == 'K' || ch == 'k' {
+				kings[piece]++
+			}
+			col++
+		}
+		if col != 8 {
+			return nil, fmt.Errorf("FEN rank %d must have 8 squares", 8-row)
+		}
+	}
+	if kings["white-king"] != 1 || kings["black-king"] != 1 {
+		return nil, errors.New("FEN must have exactly one king per side")
+	}
+
+	switch fields[1] {
+	case "w":
+		b.LastMove = "black"
+	case "b":
+		b.LastMove = "white"
+	default:
+		return nil, errors.New("FEN side to move must be w or b")
+	}
+
+	if fields[2] != "-" {
+		for _, ch := range fields[2] {
+			switch ch {
+			case 'K':
+				b.Castling.WhiteKingSide = true
+			case 'Q':
+				b.Castling.WhiteQueenSide = true
+			case 'k':
+				b.Castling.BlackKingSide = true
+			case 'q':
+				b.Castling.BlackQueenSide = true
+			default:
+				return nil, fmt.Errorf("invalid FEN castling right %q", ch)
+			}
+		}
+	}
+
+	if fields[3] != "-" {
+		ep := fields[3]
+		if len(ep) != 2 || ep[0] < 'a' || ep[0] > 'h' || (ep[1] != '3' && ep[1] != '6') {
+			return nil, errors.New("invalid FEN en passant square")
+		}
+		if !validEnPassant(&b, ep) {
+			return nil, errors.New("FEN en passant square does not follow a two-square pawn move")
+		}
+		b.EnPassant = ep
+	}
+
+	halfmove, err := strconv.Atoi(fields[4])
+	if err != nil || halfmove < 0 {
+		return nil, errors.New("invalid FEN halfmove clock")
+	}
+	fullmove, err := strconv.Atoi(fields[5])
+	if err != nil || fullmove < 1 {
+		return nil, errors.New("invalid FEN fullmove number")
+	}
+	b.HalfmoveClock = halfmove
+	b.FullmoveNumber = fullmove
+	return &b, nil
+}
+
+// validEnPassant reports whether ep can be the square skipped by the last move: it is on the side of
+// the player who just moved, their pawn stands in front of it, and the squares it crossed are empty.
+func validEnPassant(b *Board, ep string) bool {
+	col := int(ep[0] - 'a')
+	// Rows of a white pawn moved from the 2nd rank to the 4th across the 3rd, black moves from the 7th to the 5th
+	from, crossed, landed, pawn := 6, 5, 4, "white-pawn"
+	if b.LastMove == "black" {
+		from, crossed, landed, pawn = 1, 2, 3, "black-pawn"
+	}
+	return ep[1] == byte('8'-crossed) && b.Squares[landed][col] == pawn &&
+		b.Squares[crossed][col] == "" && b.Squares[from][col] == ""
+}
+
+// ToFEN returns the FEN string describing the board.
+func ToFEN(b *Board) string {
+	letters := make(map[string]rune, len(fenPieces))
+	for letter, piece := range fenPieces {
+		letters[piece] = letter
+	}
+
+	var sb strings.Builder
+	for row := 0; row < 8; row++ {
+		empty := 0
+		for col := 0; col < 8; col++ {
+			piece := b.Squares[row][col]
+			if piece == "" {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			sb.WriteRune(letters[piece])
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		if row < 7 {
+			sb.WriteByte('/')
+		}
+	}
+
+	if b.LastMove == "white" {
+		sb.WriteString(" b ")
+	} else {
+		sb.WriteString(" w ")
+	}
+
+	castling := ""
+	if b.Castling.WhiteKingSide {
+		castling += "K"
+	}
+	if b.Castling.WhiteQueenSide {
+		castling += "Q"
+	}
+	if b.Castling.BlackKingSide {
+		castling += "k"
+	}
+	if b.Castling.BlackQueenSide {
+		castling += "q"
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	sb.WriteString(castling)
+
+	ep := b.EnPassant
+	if ep == "" {
+		ep = "-"
+	}
+	fullmove := b.FullmoveNumber
+	if fullmove < 1 {
+		fullmove = 1
+	}
+	sb.WriteString(fmt.Sprintf(" %s %d %d", ep, b.HalfmoveClock, fullmove))
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+package cache
+
+import "testing"
+
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 b - - 37 80",
+	}
+	for _, fen := range fens {
+		board, err := NewBoardFromFEN(fen)
+		if err != nil {
+			t.Errorf("NewBoardFromFEN(%q): %v", fen, err)
+			continue
+		}
+		if got := ToFEN(board); got != fen {
+			t.Errorf("ToFEN(NewBoardFromFEN(%q)) = %q", fen, got)
+		}
+	}
+}
+
+func TestNewBoardFromFENSideToMove(t *testing.T) {
+	board, err := NewBoardFromFEN(StartingFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if board.LastMove != "black" {
+		t.Errorf("LastMove = %q, want black so white moves first", board.LastMove)
+	}
+	if board.Squares[7][4] != "white-king" || board.Squares[0][3] != "black-queen" {
+		t.Errorf("unexpected pieces on e1 %q and d8 %q", board.Squares[7][4], board.Squares[0][3])
+	}
+}
+
+func TestNewBoardFromFENRejectsInvalidPositions(t *testing.T) {
+	fens := map[string]string{
+		"missing field":              "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0",
+		"seven ranks":                "rnbqkbnr/pppppppp/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"long rank":                  "rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"two white kings":            "4k3/8/8/8/8/8/8/3KK3 w - - 0 1",
+		"bad side to move":           "4k3/8/8/8/8/8/8/4K3 x - - 0 1",
+		"white pawn on eighth rank":  "P3k3/8/8/8/8/8/8/4K3 w - - 0 1",
+		"black pawn on first rank":   "4k3/8/8/8/8/8/8/p3K3 w - - 0 1",
+		"en passant for wrong side":  "4k3/8/8/8/8/8/4p3/4K3 w - e3 0 1",
+		"en passant without a pawn":  "4k3/8/8/8/8/8/8/4K3 b - e3 0 1",
+		"en passant on crossed pawn": "4k3/8/8/8/4P3/4P3/8/4K3 b - e3 0 1",
+		"zero fullmove number":       "4k3/8/8/8/8/8/8/4K3 w - - 0 0",
+	}
+	for name, fen := range fens {
+		if _, err := NewBoardFromFEN(fen); err == nil {
+			t.Errorf("%s: NewBoardFromFEN(%q) succeeded, want an error", name, fen)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// MatchRequest is a player waiting in the matchmaking queue. MinRating and MaxRating are the accepted
+// opponent ratings, 0 when unbounded.
+type MatchRequest struct {
+	UserID      int64
+	TimeControl string // canonical TimeControl.String(), "" for untimed games
+	Rated       bool
+	Rating      int // rating in the pool of the time control
+	MinRating   int
+	MaxRating   int
+	QueuedAt    time.Time
+}
+
+// MatchResult is the game a queued player was paired into.
+type MatchResult struct {
+	GameID string
+	Color  string
+}
+
+type matchEntry struct {
+	MatchRequest
+	lastSeen time.Time     // last time the player asked for the entry, stale entries are dropped
+	pairing  bool          // an opponent is creating the game, the entry is not offered to anyone else
+	result   *MatchResult  // set once the game is created
+	done     chan struct{} // closed when result is set or the entry is cancelled
+}
+
+// matchQueue holds at most one entry per user, in arrival order.
+var (
+	matchQueue   []*matchEntry
+	matchQueueMu sync.Mutex
+)
+
+const (
+	// matchEntryTTL is how long a queued player may go without asking for the status before being dropped.
+	matchEntryTTL = time.Minute
+	// matchResultTTL is how long a match result stays available to a player who has not picked it up yet.
+	matchResultTTL = 5 * time.Minute
+)
+
+// accepts reports whether the request's rating range allows an opponent with the given rating.
+func (req MatchRequest) accepts(rating int) bool {
+	return (req.MinRating == 0 || rating >= req.MinRating) && (req.MaxRating == 0 || rating <= req.MaxRating)
+}
+
+// FindOrQueueMatch looks for the longest waiting compatible opponent: same time control, both asking for
+// a rated or both for a casual game, and each player within the other's rating range. If one is found
+// it is reserved and returned, and the caller must create the game and call CompleteMatch (or
+// ReleaseMatch on failure). Otherwise the request is queued, replacing any previous entry of the same
+// user unless an opponent is already creating a game with it.
+func FindOrQueueMatch(req MatchRequest) *MatchRequest {
+	matchQueueMu.Lock()
+	defer matchQueueMu.Unlock()
+
+	if entry := findMatchEntryLocked(req.UserID); entry != nil && entry.pairing && entry.result == nil {
+		return nil
+	}
+	removeMatchEntryLocked(req.UserID)
+	for _, entry := range matchQueue {
+		if entry.pairing || entry.result != nil || entry.UserID == req.UserID || entry.TimeControl != req.TimeControl || entry.Rated != req.Rated {
+			continue
+		}
+		if !entry.accepts(req.Rating) || !req.accepts(entry.Rating) {
+			continue
+		}
+		entry.pairing = true
+		opponent := entry.MatchRequest
+		return &opponent
+	}
+	matchQueue = append(matchQueue, &matchEntry{MatchRequest: req, lastSeen: time.Now(), done: make(chan struct{})})
+	return nil
+}
+
+// CompleteMatch hands the created game to a reserved opponent and wakes up its waiting requests.
+func CompleteMatch(userID int64, result MatchResult) {
+	matchQueueMu.Lock()
+	defer matchQueueMu.Unlock()
+	if entry := findMatchEntryLocked(userID); entry != nil && entry.result == nil {
+		entry.result = &result
+		entry.lastSeen = time.Now()
+		close(entry.done)
+	}
+}
+
+// ReleaseMatch puts a reserved opponent back in the queue when the game could not be created.
+func ReleaseMatch(userID int64) {
+	matchQueueMu.Lock()
+	defer matchQueueMu.Unlock()
+	if entry := findMatchEntryLocked(userID); entry != nil {
+		entry.pairing = false
+	}
+}
+
+// GetMatchStatus returns the queue entry of a user: whether the user is queued, the match result once
+// paired, and a channel closed when the entry changes. The channel is nil when the user is not queued.
+func GetMatchStatus(userID int64) (*MatchRequest, *MatchResult, <-chan struct{}) {
+	matchQueueMu.Lock()
+	defer matchQueueMu.Unlock()
+	entry := findMatchEntryLocked(userID)
+	if entry == nil {
+		return nil, nil, nil
+	}
+	if entry.result == nil {
+		entry.lastSeen = time.Now()
+	}
+	req := entry.MatchRequest
+	return &req, entry.result, entry.done
+}
+
+// CancelMatch removes a user from the queue. It returns false if the user was not waiting,
+// including when an opponent is already creating the game.
+func CancelMatch(userID int64) bool {
+	matchQueueMu.Lock()
+	defer matchQueueMu.Unlock()
+	entry := findMatchEntryLocked(userID)
+	if entry == nil || entry.pairing || entry.result != nil {
+		return false
+	}
+	removeMatchEntryLocked(userID)
+	return true
+}
+
+// CleanExpiredMatches removes players who stopped polling the queue and match results nobody picked up.
+func CleanExpiredMatches() {
+	matchQueueMu.Lock()
+	defer matchQueueMu.Unlock()
+	now := time.Now()
+	kept := matchQueue[:0]
+	for _, entry := range matchQueue {
+		switch {
+		case entry.result != nil && now.Sub(entry.lastSeen) > matchResultTTL:
+		case entry.result == nil && !entry.pairing && now.Sub(entry.lastSeen) > matchEntryTTL:
+			close(entry.done)
+		default:
+			kept = append(kept, entry)
+		}
+	}
+	matchQueue = kept
+}
+
+func findMatchEntryLocked(userID int64) *matchEntry {
+	for _, entry := range matchQueue {
+		if entry.UserID == userID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// removeMatchEntryLocked drops the user's entry and wakes up requests still waiting on it.
+func removeMatchEntryLocked(userID int64) {
+	for i, entry := range matchQueue {
+		if entry.UserID == userID {
+			if entry.result == nil {
+				close(entry.done)
+			}
+			matchQueue = append(matchQueue[:i], matchQueue[i+1:]...)
+			return
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 type sessionCacheEntry struct {
 	UserID    int64
 	ExpiresAt time.Time
+	SeenAt    time.Time // last time the session's last_seen_at was written to the database
 }
 
 var (
@@ -33,10 +34,26 @@ func SetUserIDForToken(token string, userID int64, expiresAt time.Time) {
 	sessionCache[token] = &sessionCacheEntry{
 		UserID:    userID,
 		ExpiresAt: expiresAt,
+		SeenAt:    time.Now(),
 	}
 	sessionCacheMu.Unlock()
 }
 
+// SessionSeen records a request with a cached session token and reports whether the session should be
+// written to the database, which happens at most once per interval. When it returns true the cached
+// expiry is moved to expiresAt, which the caller must store as well.
+func SessionSeen(token string, interval time.Duration, expiresAt time.Time) bool {
+	sessionCacheMu.Lock()
+	defer sessionCacheMu.Unlock()
+	entry, ok := sessionCache[token]
+	if !ok || time.Since(entry.SeenAt) < interval {
+		return false
+	}
+	entry.SeenAt = time.Now()
+	entry.ExpiresAt = expiresAt
+	return true
+}
+
 // DeleteUserIDForToken removes a session token from the cache.
 func DeleteUserIDForToken(token string) {
 	sessionCacheMu.Lock()
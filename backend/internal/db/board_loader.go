@@ -0,0 +1,139 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/movevalidation"
+	"gophermatebackend/internal/utils"
+)
+
+// ErrUnreplayable is returned by BuildBoard when a stored move can no longer be replayed.
+var ErrUnreplayable = errors.New("game cannot be replayed")
+
+// LoadBoard returns the board of an unfinished game. When the board is missing from the cache
+// (server restart or inactivity) it is rebuilt by replaying the game's moves from its starting
+// position and cached again. Returns nil and no error for finished or unknown games, and an error
+// wrapping ErrUnreplayable if a stored move can no longer be replayed.
+func LoadBoard(dbConn *sql.DB, gameID string) (*cache.Board, error) {
+	if board := cache.GetBoard(gameID); board != nil {
+		return board, nil
+	}
+
+	game, err := GetGame(dbConn, gameID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if game.FinishedAt.Valid {
+		return nil, nil
+	}
+
+	board, err := BuildBoard(dbConn, game)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo(fmt.Sprintf("LoadBoard: rebuilt board for game %s from %d moves", gameID, board.LastMoveNumber))
+	cache.SetBoard(gameID, board)
+	return board, nil
+}
+
+// LoadBoardCopy is LoadBoard for readers: it returns a copy of the board taken under the board's
+// lock, so a move being applied is never seen half done.
+func LoadBoardCopy(dbConn *sql.DB, gameID string) (*cache.Board, error) {
+	unlock := cache.LockBoard(gameID)
+	defer unlock()
+	board, err := LoadBoard(dbConn, gameID)
+	if board == nil || err != nil {
+		return nil, err
+	}
+	return board.Copy(), nil
+}
+
+// BuildBoard replays all moves of a game from its starting position, without using the cache.
+// It also works for finished games, whose boards are no longer cached.
+func BuildBoard(dbConn *sql.DB, game *Game) (*cache.Board, error) {
+	board, err := StartingBoard(game.InitialFEN.String)
+	if err != nil {
+		return nil, fmt.Errorf("invalid initial FEN for game %s: %w", game.ID, err)
+	}
+
+	moves, err := GetMoves(dbConn, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	board.Clock, err = gameClock(game)
+	if err != nil {
+		return nil, err
+	}
+	if err := ReplayMoves(board, moves, nil); err != nil {
+		return nil, fmt.Errorf("%w: game %s: %v", ErrUnreplayable, game.ID, err)
+	}
+	return board, nil
+}
+
+// StartingBoard returns the position a game starts from: the given FEN, or the standard starting
+// position if it is empty.
+func StartingBoard(initialFEN string) (*cache.Board, error) {
+	if initialFEN == "" {
+		return cache.NewInitialBoard(), nil
+	}
+	return cache.NewBoardFromFEN(initialFEN)
+}
+
+// ReplayedMove is a stored move as replayed by ReplayMoves. Color is the side that played it.
+type ReplayedMove struct {
+	Number int
+	Color  string
+	Move   movevalidation.MoveData
+	SAN    string
+}
+
+// ReplayError reports a stored move that could not be replayed. Ply is 1-based.
+type ReplayError struct {
+	Ply      int
+	Notation string
+	Err      error
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("move %d (%s): %v", e.Ply, e.Notation, e.Err)
+}
+
+func (e *ReplayError) Unwrap() error {
+	return e.Err
+}
+
+// ReplayMoves applies the moves to the board in order and keeps its last move number and notation
+// up to date. visit is called after each move with the move and its SAN; when it is nil the SAN,
+// which needs every legal move of the position, is not computed.
+func ReplayMoves(board *cache.Board, moves []Move, visit func(ReplayedMove) error) error {
+	for i, m := range moves {
+		replayed := ReplayedMove{Number: m.Number, Color: movevalidation.SideToMove(board)}
+		if replayed.Number == 0 {
+			replayed.Number = i + 1
+		}
+		var err error
+		if visit == nil {
+			replayed.Move, err = movevalidation.ApplyStoredMove(board, m.Notation, m.UCI)
+		} else {
+			replayed.Move, replayed.SAN, err = movevalidation.ReplayMove(board, m.Notation, m.UCI)
+		}
+		if err != nil {
+			return &ReplayError{Ply: i + 1, Notation: m.Notation, Err: err}
+		}
+		board.LastMoveNumber = replayed.Number
+		board.LastMoveNotation = m.Notation
+		if visit != nil {
+			if err := visit(replayed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
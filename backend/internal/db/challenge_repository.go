@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gophermatebackend/internal/cache"
+)
+
+// Challenge is a direct game invitation from one user to another.
+type Challenge struct {
+	ID             string
+	ChallengerID   int64
+	ChallengerName string
+	RecipientID    int64
+	RecipientName  string
+	TimeControl    string // "" for untimed games
+	Color          string // challenger's color preference: "white", "black" or "random"
+	Rated          bool
+	Status         string // "pending", "accepted", "declined" or "cancelled"
+	GameID         sql.NullString
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	AnsweredAt     sql.NullTime
+}
+
+// answeredChallengeTTL is how long an answered challenge stays listed, so the challenger learns the
+// answer (and the game of an accepted challenge) even if the challenge would have expired meanwhile.
+const answeredChallengeTTL = 24 * time.Hour
+
+const challengeColumns = `c.id, c.challenger_id, u1.username, c.recipient_id, u2.username, COALESCE(c.time_control, ''),
+	c.color, c.rated, c.status, c.game_id, c.created_at, c.expires_at, c.answered_at
+	FROM challenges c
+	JOIN users u1 ON u1.id = c.challenger_id
+	JOIN users u2 ON u2.id = c.recipient_id`
+
+// CreateChallenge stores a pending challenge that expires after the given duration and returns its ID.
+func CreateChallenge(dbConn *sql.DB, challengerID int64, recipientID int64, timeControl string, color string, rated bool, expiry time.Duration) (string, error) {
+	var id string
+	query := `INSERT INTO challenges (challenger_id, recipient_id, time_control, color, rated, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NOW() + make_interval(secs => $6)) RETURNING id`
+	err := dbConn.QueryRow(query, challengerID, recipientID, timeControl, color, rated, expiry.Seconds()).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to create challenge: %w", err)
+	}
+	return id, nil
+}
+
+// GetChallenge returns a challenge by ID, or sql.ErrNoRows if it does not exist.
+func GetChallenge(dbConn *sql.DB, challengeID string) (*Challenge, error) {
+	row := dbConn.QueryRow(`SELECT `+challengeColumns+` WHERE c.id = $1`, challengeID)
+	var c Challenge
+	if err := scanChallenge(row, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetUserChallenges returns the pending, unexpired challenges sent or received by a user, newest first.
+// Answered challenges stay listed for answeredChallengeTTL so the challenger can see the answer.
+func GetUserChallenges(dbConn *sql.DB, userID int64) ([]Challenge, error) {
+	query := `SELECT ` + challengeColumns + `
+		WHERE (c.challenger_id = $1 OR c.recipient_id = $1)
+			AND ((c.status = 'pending' AND c.expires_at > NOW()) OR c.answered_at > NOW() - make_interval(secs => $2))
+		ORDER BY c.created_at DESC`
+	rows, err := dbConn.Query(query, userID, answeredChallengeTTL.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenges: %w", err)
+	}
+	defer rows.Close()
+
+	var challenges []Challenge
+	for rows.Next() {
+		var c Challenge
+		if err := scanChallenge(rows, &c); err != nil {
+			return nil, fmt.Errorf("failed to scan challenge: %w", err)
+		}
+		challenges = append(challenges, c)
+	}
+	return challenges, rows.Err()
+}
+
+// AcceptChallenge marks a pending, unexpired challenge to the recipient as accepted and creates its game
+// in the same transaction. Returns sql.ErrNoRows if the challenge can no longer be accepted.
+func AcceptChallenge(dbConn *sql.DB, challengeID string, recipientID int64, playerWhiteID int64, playerBlackID int64, timeControl cache.TimeControl, rated bool) (string, error) {
+	tx, err := dbConn.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE challenges SET status = 'accepted', answered_at = NOW()
+		WHERE id = $1 AND recipient_id = $2 AND status = 'pending' AND expires_at > NOW()`, challengeID, recipientID)
+	if err != nil {
+		return "", fmt.Errorf("failed to accept challenge: %w", err)
+	}
+	if count, err := res.RowsAffected(); err != nil || count == 0 {
+		return "", sql.ErrNoRows
+	}
+
+	gameID, err := insertMatchedGame(tx, playerWhiteID, playerBlackID, timeControl, rated, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create game: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE challenges SET game_id = $1 WHERE id = $2`, gameID, challengeID); err != nil {
+		return "", fmt.Errorf("failed to link game: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit challenge: %w", err)
+	}
+	return gameID, nil
+}
+
+// AnswerChallenge sets the status of a pending, unexpired challenge: "declined" by its recipient or
+// "cancelled" by its challenger. Returns sql.ErrNoRows if the user cannot change the challenge.
+func AnswerChallenge(dbConn *sql.DB, challengeID string, userID int64, status string) error {
+	column := "recipient_id"
+	if status == "cancelled" {
+		column = "challenger_id"
+	}
+	res, err := dbConn.Exec(`UPDATE challenges SET status = $1, answered_at = NOW()
+		WHERE id = $2 AND `+column+` = $3 AND status = 'pending' AND expires_at > NOW()`, status, challengeID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update challenge: %w", err)
+	}
+	if count, err := res.RowsAffected(); err != nil || count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanChallenge(row interface{ Scan(...interface{}) error }, c *Challenge) error {
+	return row.Scan(&c.ID, &c.ChallengerID, &c.ChallengerName, &c.RecipientID, &c.RecipientName, &c.TimeControl,
+		&c.Color, &c.Rated, &c.Status, &c.GameID, &c.CreatedAt, &c.ExpiresAt, &c.AnsweredAt)
+}
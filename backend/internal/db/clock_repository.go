@@ -0,0 +1,75 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gophermatebackend/internal/cache"
+)
+
+// saveClock stores the remaining times of a game after a move, along with the deadline of the side
+// to move so the flag-fall sweeper can find games that ran out of time without loading their boards.
+func saveClock(tx *sql.Tx, gameID string, clock cache.Clock, sideToMove string) error {
+	var turnStartedAt, deadline sql.NullTime
+	if clock.Running() {
+		turnStartedAt = sql.NullTime{Time: clock.TurnStartedAt.UTC(), Valid: true}
+		deadline = sql.NullTime{Time: clock.Deadline(sideToMove).UTC(), Valid: true}
+	}
+	timed := clock.TimeControl.IsTimed()
+	query := `UPDATE games SET white_time_ms = $1, black_time_ms = $2, turn_started_at = $3, turn_deadline = $4 WHERE id = $5`
+	_, err := tx.Exec(query, clockMillis(clock.WhiteRemaining, timed), clockMillis(clock.BlackRemaining, timed),
+		turnStartedAt, deadline, gameID)
+	if err != nil {
+		return fmt.Errorf("failed to save clock: %w", err)
+	}
+	return nil
+}
+
+// GetTimedOutGames returns the IDs of unfinished games whose side to move has passed its deadline.
+// Games still waiting for an opponent never time out.
+func GetTimedOutGames(dbConn *sql.DB) ([]string, error) {
+	query := `SELECT id FROM games WHERE finished_at IS NULL AND turn_deadline <= $1
+		AND player_white_id IS NOT NULL AND player_black_id IS NOT NULL`
+	rows, err := dbConn.Query(query, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timed out games: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan game: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// gameClock rebuilds the clock of a game from its stored time control and remaining times.
+func gameClock(game *Game) (cache.Clock, error) {
+	tc, err := cache.ParseTimeControl(game.TimeControl.String)
+	if err != nil {
+		return cache.Clock{}, fmt.Errorf("invalid time control for game %s: %w", game.ID, err)
+	}
+	clock := cache.NewClock(tc)
+	if game.WhiteTimeMs.Valid {
+		clock.WhiteRemaining = time.Duration(game.WhiteTimeMs.Int64) * time.Millisecond
+	}
+	if game.BlackTimeMs.Valid {
+		clock.BlackRemaining = time.Duration(game.BlackTimeMs.Int64) * time.Millisecond
+	}
+	if game.TurnStartedAt.Valid {
+		// Stored as UTC in a column without time zone
+		t := game.TurnStartedAt.Time
+		clock.TurnStartedAt = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	return clock, nil
+}
+
+// clockMillis converts a remaining time to the value stored in the games table, NULL for untimed games.
+func clockMillis(d time.Duration, timed bool) sql.NullInt64 {
+	return sql.NullInt64{Int64: d.Milliseconds(), Valid: timed}
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned by paginated queries when the cursor was not produced by a previous page.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorTimestamp formats timestamps like Postgres so the cursor compares exactly with the column.
+const cursorTimestamp = "2006-01-02 15:04:05.999999"
+
+// pageCursor is the position of the last game of a page in the sort order: its ID, the timestamp
+// it is sorted by and, when sorting by rating, its rating.
+type pageCursor struct {
+	Rating int    `json:"r,omitempty"`
+	Time   string `json:"t"`
+	ID     string `json:"i"`
+}
+
+// encodeCursor returns the opaque cursor handed to clients for the next page.
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &cursor) != nil {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	if _, err := time.Parse(cursorTimestamp, cursor.Time); err != nil {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
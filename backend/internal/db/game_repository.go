@@ -10,16 +10,59 @@ import (
 	"github.com/google/uuid"
 )
 
-// SetGameDraw sets the game as finished with a draw in the database
-func SetGameDraw(dbConn *sql.DB, gameID string) error {
-	_, err := dbConn.Exec(`UPDATE games SET winner = $1, finished_at = NOW() WHERE id = $2`, "draw", gameID)
-	return err
+// SetGameFinished sets the winner ("white", "black" or "draw"), the reason and finished_at for a game.
+// Rated games update both players' ratings in the same transaction.
+// Returns sql.ErrNoRows if the game does not exist or is already finished.
+func SetGameFinished(dbConn *sql.DB, gameID string, winner string, reason string) error {
+	tx, err := dbConn.Begin()
+	if err != nil {
+		log.Printf("SetGameFinished: Failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var whiteID, blackID sql.NullInt64
+	var rated bool
+	var category sql.NullString
+	query := `UPDATE games SET winner = $1, result_reason = $2, finished_at = NOW() WHERE id = $3 AND finished_at IS NULL
+		RETURNING player_white_id, player_black_id, rated, rating_category`
+	if err := tx.QueryRow(query, winner, reason, gameID).Scan(&whiteID, &blackID, &rated, &category); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("SetGameFinished: Failed to update game: %v", err)
+		}
+		return err
+	}
+	if rated && whiteID.Valid && blackID.Valid && category.Valid {
+		if err := updateRatings(tx, gameID, whiteID.Int64, blackID.Int64, category.String, winner); err != nil {
+			log.Printf("SetGameFinished: Failed to update ratings: %v", err)
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("SetGameFinished: Failed to commit: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetGameResult returns the winner and reason of a finished game, or "" and "" if it is still running.
+func GetGameResult(dbConn *sql.DB, gameID string) (string, string, error) {
+	var winner, reason sql.NullString
+	query := `SELECT winner, result_reason FROM games WHERE id = $1 AND finished_at IS NOT NULL`
+	err := dbConn.QueryRow(query, gameID).Scan(&winner, &reason)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return winner.String, reason.String, nil
 }
 
 // GetLastMove returns the last move number and notation for a game, or 0 and "" if none.
 func GetLastMove(db *sql.DB, gameID string) (int, string, error) {
 	// Check cache first
-	board := cache.GetBoard(gameID)
+	board := cache.GetBoardCopy(gameID)
 	if board != nil {
 		return board.LastMoveNumber, board.LastMoveNotation, nil
 	}
@@ -44,72 +87,142 @@ func GetLastMove(db *sql.DB, gameID string) (int, string, error) {
 	return moveNumber, notation, nil
 }
 
-// JoinGameAsBlack sets the player_black_id for a game if not already set.
-func JoinGameAsBlack(db *sql.DB, gameID string, userID int64) error {
-	// Only allow joining if player_black_id is NULL
-	res, err := db.Exec(`UPDATE games SET player_black_id = $1 WHERE id = $2 AND player_black_id IS NULL`, userID, gameID)
+// JoinGame seats the user in whichever seat of an unfinished public game is empty and returns the color taken.
+// Returns sql.ErrNoRows if the game does not exist, is private, is full or the user already plays in it.
+func JoinGame(db *sql.DB, gameID string, userID int64) (string, error) {
+	query := `UPDATE games SET
+			player_white_id = COALESCE(player_white_id, $1),
+			player_black_id = CASE WHEN player_white_id IS NULL THEN player_black_id ELSE $1 END
+		WHERE id = $2 AND invite_code IS NULL AND finished_at IS NULL AND (player_white_id IS NULL OR player_black_id IS NULL)
+			AND player_white_id IS DISTINCT FROM $1 AND player_black_id IS DISTINCT FROM $1
+		RETURNING CASE WHEN player_white_id = $1 THEN 'white' ELSE 'black' END`
+	var color string
+	err := db.QueryRow(query, userID, gameID).Scan(&color)
 	if err != nil {
-		log.Printf("JoinGameAsBlack: Failed to update game: %v", err)
-		return err
+		if err != sql.ErrNoRows {
+			log.Printf("JoinGame: Failed to update game: %v", err)
+		}
+		return "", err
 	}
-	count, err := res.RowsAffected()
+	return color, nil
+}
+
+// JoinGameByInviteCode seats the user in the empty seat of the private game with the invite code.
+// Returns the game ID and the color taken, or sql.ErrNoRows like JoinGame.
+func JoinGameByInviteCode(db *sql.DB, inviteCode string, userID int64) (string, string, error) {
+	query := `UPDATE games SET
+			player_white_id = COALESCE(player_white_id, $1),
+			player_black_id = CASE WHEN player_white_id IS NULL THEN player_black_id ELSE $1 END
+		WHERE invite_code = $2 AND finished_at IS NULL AND (player_white_id IS NULL OR player_black_id IS NULL)
+			AND player_white_id IS DISTINCT FROM $1 AND player_black_id IS DISTINCT FROM $1
+		RETURNING id, CASE WHEN player_white_id = $1 THEN 'white' ELSE 'black' END`
+	var gameID, color string
+	err := db.QueryRow(query, userID, inviteCode).Scan(&gameID, &color)
 	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return sql.ErrNoRows
+		if err != sql.ErrNoRows {
+			log.Printf("JoinGameByInviteCode: Failed to update game: %v", err)
+		}
+		return "", "", err
 	}
-	return nil
+	return gameID, color, nil
 }
 
 type Game struct {
-	ID          string
-	PlayerWhite sql.NullInt64
-	PlayerBlack sql.NullInt64
-	Winner      sql.NullString
-	CreatedAt   string
-	FinishedAt  sql.NullString
+	ID            string
+	PlayerWhite   sql.NullInt64
+	PlayerBlack   sql.NullInt64
+	Winner        sql.NullString
+	CreatedAt     time.Time
+	FinishedAt    sql.NullTime
+	ResultReason  sql.NullString
+	InitialFEN    sql.NullString
+	WhiteUsername sql.NullString
+	BlackUsername sql.NullString
+	TimeControl   sql.NullString
+	WhiteTimeMs   sql.NullInt64
+	BlackTimeMs   sql.NullInt64
+	TurnStartedAt sql.NullTime
+	Rated         bool
+	InviteCode    sql.NullString // code to join a private game
+	RematchOf     sql.NullString // previous game when this game is a rematch
+	RematchOffer  sql.NullString // color with a pending rematch offer on this finished game
+	RematchGameID sql.NullString // rematch created from this game, if any
 }
 
-func GetOpenGames(db *sql.DB) ([]Game, error) {
-	query := `SELECT id, player_white_id, player_black_id FROM games WHERE finished_at IS NULL`
-	rows, err := db.Query(query)
+// GetGame returns a game with its players' usernames (or the recorded names of an imported game),
+// or sql.ErrNoRows if it does not exist.
+func GetGame(db *sql.DB, gameID string) (*Game, error) {
+	query := `SELECT g.id, g.player_white_id, g.player_black_id, g.winner, g.created_at, g.finished_at,
+			g.result_reason, g.initial_fen, COALESCE(w.username, g.white_name), COALESCE(b.username, g.black_name),
+			g.time_control, g.white_time_ms, g.black_time_ms, g.turn_started_at, g.rated, g.invite_code,
+			g.rematch_of, g.rematch_offered_by, (SELECT r.id FROM games r WHERE r.rematch_of = g.id)
+		FROM games g
+		LEFT JOIN users w ON w.id = g.player_white_id
+		LEFT JOIN users b ON b.id = g.player_black_id
+		WHERE g.id = $1`
+	var game Game
+	err := db.QueryRow(query, gameID).Scan(&game.ID, &game.PlayerWhite, &game.PlayerBlack, &game.Winner, &game.CreatedAt,
+		&game.FinishedAt, &game.ResultReason, &game.InitialFEN, &game.WhiteUsername, &game.BlackUsername,
+		&game.TimeControl, &game.WhiteTimeMs, &game.BlackTimeMs, &game.TurnStartedAt, &game.Rated, &game.InviteCode,
+		&game.RematchOf, &game.RematchOffer, &game.RematchGameID)
 	if err != nil {
-		log.Printf("GetOpenGames: Failed to execute query: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
+	return &game, nil
+}
 
-	var games []Game
-	for rows.Next() {
-		var game Game
-		if err := rows.Scan(&game.ID, &game.PlayerWhite, &game.PlayerBlack); err != nil {
-			log.Printf("GetOpenGames: Failed to scan row: %v", err)
-			return nil, err
-		}
-		games = append(games, game)
+// CreateGame inserts a new game into the database and returns the game ID. The creator takes the seat
+// of the given color ("white" or "black") and the other seat stays empty until someone joins.
+// initialFEN is the custom starting position, or "" for the standard start.
+// Both clocks start with the base time of the time control; the zero TimeControl is an untimed game.
+// inviteCode makes the game private, "" creates a public game listed in the lobby.
+// A rated game updates the players' ratings in the pool of its time control when it finishes.
+func CreateGame(db *sql.DB, creatorID int64, color string, initialFEN string, timeControl cache.TimeControl, inviteCode string, rated bool) (string, error) {
+	var playerWhiteID, playerBlackID sql.NullInt64
+	if color == "black" {
+		playerBlackID = sql.NullInt64{Int64: creatorID, Valid: true}
+	} else {
+		playerWhiteID = sql.NullInt64{Int64: creatorID, Valid: true}
 	}
-
-	if err := rows.Err(); err != nil {
-		log.Printf("GetOpenGames: Row iteration error: %v", err)
-		return nil, err
+	gameID := uuid.New().String()
+	query := `INSERT INTO games (id, player_white_id, player_black_id, initial_fen, time_control, rating_category, rated,
+			white_time_ms, black_time_ms, invite_code)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8, $8, NULLIF($9, ''))`
+	_, err := db.Exec(query, gameID, playerWhiteID, playerBlackID, initialFEN, timeControl.String(), timeControl.Category(), rated,
+		clockMillis(timeControl.Base, timeControl.IsTimed()), inviteCode)
+	if err != nil {
+		log.Printf("CreateGame: Failed to insert game: %v", err)
+		return "", err
 	}
-
-	return games, nil
+	return gameID, nil
 }
 
-// CreateGame inserts a new game into the database and returns the game ID.
-func CreateGame(db *sql.DB, playerWhiteID int64) (string, error) {
-	gameID := uuid.New().String()
-	query := `INSERT INTO games (id, player_white_id) VALUES ($1, $2)`
-	_, err := db.Exec(query, gameID, playerWhiteID)
+// CreateMatchedGame inserts a game between two paired players and returns the game ID.
+func CreateMatchedGame(db *sql.DB, playerWhiteID int64, playerBlackID int64, timeControl cache.TimeControl, rated bool) (string, error) {
+	gameID, err := insertMatchedGame(db, playerWhiteID, playerBlackID, timeControl, rated, "")
 	if err != nil {
-		log.Printf("CreateGame: Failed to insert game: %v", err)
+		log.Printf("CreateMatchedGame: Failed to insert game: %v", err)
 		return "", err
 	}
 	return gameID, nil
 }
 
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertMatchedGame inserts a game with both seats taken. rematchOf links it to the previous game, "" if none.
+func insertMatchedGame(db execer, playerWhiteID int64, playerBlackID int64, timeControl cache.TimeControl, rated bool, rematchOf string) (string, error) {
+	gameID := uuid.New().String()
+	query := `INSERT INTO games (id, player_white_id, player_black_id, time_control, rating_category, rated,
+			white_time_ms, black_time_ms, rematch_of)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $7, NULLIF($8, '')::uuid)`
+	_, err := db.Exec(query, gameID, playerWhiteID, playerBlackID, timeControl.String(), timeControl.Category(), rated,
+		clockMillis(timeControl.Base, timeControl.IsTimed()), rematchOf)
+	return gameID, err
+}
+
 // ValidateUserInGameSession checks if the user is a participant in the game (white or black)
 func ValidateUserInGameSession(db *sql.DB, gameID string, userID int64) (bool, error) {
 	// Generate cache key for this game-user combination
@@ -136,13 +249,18 @@ func ValidateUserInGameSession(db *sql.DB, gameID string, userID int64) (bool, e
 	return isValid, nil
 }
 
-// SetGameResigned sets the winner and finished_at for a game when a player resigns
-func SetGameResigned(db *sql.DB, gameID string, winner string) error {
-	query := `UPDATE games SET winner = $1, finished_at = NOW() WHERE id = $2`
-	_, err := db.Exec(query, winner, gameID)
-	if err != nil {
-		log.Printf("SetGameResigned: Failed to update game: %v", err)
-		return err
+// CanViewGame checks if the user may follow or review a game: its players, and for games imported
+// from PGN, the user who imported them. It is meant for read-only access; moves and offers still
+// require ValidateUserInGameSession.
+func CanViewGame(db *sql.DB, gameID string, userID int64) (bool, error) {
+	ok, err := ValidateUserInGameSession(db, gameID, userID)
+	if err != nil || ok {
+		return ok, err
 	}
-	return nil
+	var count int
+	query := `SELECT COUNT(1) FROM games WHERE id = $1 AND imported_by = $2`
+	if err := db.QueryRow(query, gameID, userID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
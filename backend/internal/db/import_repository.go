@@ -0,0 +1,49 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImportedGame is a finished game read from a PGN file. Its players are stored by name only.
+type ImportedGame struct {
+	WhiteName  string
+	BlackName  string
+	Winner     string // "white", "black", "draw", or "" if the PGN result is unknown
+	PlayedAt   time.Time
+	InitialFEN string // "" for the standard start
+	Moves      []Move // Notation (SAN) and UCI of each move
+	ImportedBy int64
+}
+
+// CreateImportedGame stores an imported game and its moves in a single transaction and returns the game ID.
+func CreateImportedGame(dbConn *sql.DB, game ImportedGame) (string, error) {
+	tx, err := dbConn.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	gameID := uuid.New().String()
+	query := `INSERT INTO games (id, winner, created_at, finished_at, initial_fen, white_name, black_name, imported_by)
+		VALUES ($1, NULLIF($2, ''), $3, NOW(), NULLIF($4, ''), $5, $6, $7)`
+	_, err = tx.Exec(query, gameID, game.Winner, game.PlayedAt, game.InitialFEN, game.WhiteName, game.BlackName, game.ImportedBy)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert imported game: %w", err)
+	}
+
+	for i, m := range game.Moves {
+		query := `INSERT INTO moves (game_id, move_number, notation, uci) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.Exec(query, gameID, i+1, m.Notation, m.UCI); err != nil {
+			return "", fmt.Errorf("failed to insert imported move: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit imported game: %w", err)
+	}
+	return gameID, nil
+}
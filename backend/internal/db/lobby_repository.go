@@ -0,0 +1,166 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"gophermatebackend/internal/rating"
+)
+
+const (
+	LobbySortNewest = "newest"
+	LobbySortOldest = "oldest"
+	LobbySortRating = "rating"
+
+	// LobbyUntimed filters the lobby on games without a clock.
+	LobbyUntimed = "untimed"
+
+	LobbyRated  = "rated"
+	LobbyCasual = "casual"
+)
+
+// LobbyFilter selects the games listed by GetOpenGames. The zero value lists every public unfinished game, newest first.
+type LobbyFilter struct {
+	WaitingOnly bool   // only games with an empty seat
+	TimeControl string // canonical time control, LobbyUntimed, or "" for any
+	Mode        string // LobbyRated, LobbyCasual, or "" for any
+	MinRating   int    // rating range of the game, 0 when unbounded
+	MaxRating   int
+	UserID      int64  // only the games of this user, including their private games; 0 for every public game
+	Sort        string // LobbySortNewest (default), LobbySortOldest or LobbySortRating
+	Cursor      string // cursor returned with the previous page, "" for the first page
+	Limit       int    // page size, must be positive
+}
+
+// LobbyGame is a game listed in the lobby with the ratings of its seated players in the pool of its
+// time control, NULL for untimed games. Rating is their average, which the rating filter and sort apply to.
+type LobbyGame struct {
+	Game
+	WhiteRating      sql.NullInt64
+	BlackRating      sql.NullInt64
+	WhiteProvisional bool
+	BlackProvisional bool
+	Rating           int
+}
+
+// GetOpenGames returns a page of unfinished games matching the filter, and the cursor of the next page
+// ("" on the last page). Private games, joined by invite code, and games created from a challenge or
+// a rematch are only listed among the games of their players.
+// Games without rated players, such as untimed games, sort and filter as DefaultRating.
+func GetOpenGames(db *sql.DB, filter LobbyFilter) ([]LobbyGame, string, error) {
+	args := []interface{}{DefaultRating, rating.DefaultDeviation, rating.ProvisionalDeviation}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+	if filter.UserID != 0 {
+		p := arg(filter.UserID)
+		conditions = append(conditions, "(player_white_id = "+p+" OR player_black_id = "+p+")")
+	} else {
+		conditions = append(conditions, "NOT private")
+	}
+	if filter.WaitingOnly {
+		conditions = append(conditions, "(player_white_id IS NULL OR player_black_id IS NULL)")
+	}
+	switch filter.TimeControl {
+	case "":
+	case LobbyUntimed:
+		conditions = append(conditions, "time_control IS NULL")
+	default:
+		conditions = append(conditions, "time_control = "+arg(filter.TimeControl))
+	}
+	switch filter.Mode {
+	case LobbyRated:
+		conditions = append(conditions, "rated")
+	case LobbyCasual:
+		conditions = append(conditions, "NOT rated")
+	}
+	if filter.MinRating > 0 {
+		conditions = append(conditions, "rating >= "+arg(filter.MinRating))
+	}
+	if filter.MaxRating > 0 {
+		conditions = append(conditions, "rating <= "+arg(filter.MaxRating))
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		switch filter.Sort {
+		case LobbySortOldest:
+			conditions = append(conditions, fmt.Sprintf("(created_at, id) > (%s::timestamp, %s::uuid)",
+				arg(cursor.Time), arg(cursor.ID)))
+		case LobbySortRating:
+			conditions = append(conditions, fmt.Sprintf("(rating, created_at, id) < (%s::int, %s::timestamp, %s::uuid)",
+				arg(cursor.Rating), arg(cursor.Time), arg(cursor.ID)))
+		default:
+			conditions = append(conditions, fmt.Sprintf("(created_at, id) < (%s::timestamp, %s::uuid)",
+				arg(cursor.Time), arg(cursor.ID)))
+		}
+	}
+
+	order := "created_at DESC, id DESC"
+	switch filter.Sort {
+	case LobbySortOldest:
+		order = "created_at, id"
+	case LobbySortRating:
+		order = "rating DESC, created_at DESC, id DESC"
+	}
+
+	// One extra row tells whether there is a next page
+	query := `SELECT id, player_white_id, player_black_id, white_username, black_username, time_control, rated,
+			created_at, white_rating, black_rating, white_provisional, black_provisional, rating
+		FROM (
+			SELECT g.id, g.player_white_id, g.player_black_id, w.username AS white_username, b.username AS black_username,
+				g.time_control, g.rated, g.created_at, r.white_rating, r.black_rating,
+				g.invite_code IS NOT NULL OR g.rematch_of IS NOT NULL
+					OR EXISTS (SELECT 1 FROM challenges c WHERE c.game_id = g.id) AS private,
+				COALESCE(wr.deviation, $2) > $3 AS white_provisional, COALESCE(br.deviation, $2) > $3 AS black_provisional,
+				COALESCE((COALESCE(r.white_rating, r.black_rating) + COALESCE(r.black_rating, r.white_rating)) / 2, $1::int) AS rating
+			FROM games g
+			LEFT JOIN users w ON w.id = g.player_white_id
+			LEFT JOIN users b ON b.id = g.player_black_id
+			LEFT JOIN ratings wr ON wr.user_id = g.player_white_id AND wr.category = g.rating_category
+			LEFT JOIN ratings br ON br.user_id = g.player_black_id AND br.category = g.rating_category
+			CROSS JOIN LATERAL (SELECT
+				CASE WHEN g.player_white_id IS NOT NULL AND g.rating_category IS NOT NULL
+					THEN COALESCE(ROUND(wr.rating)::int, $1::int) END AS white_rating,
+				CASE WHEN g.player_black_id IS NOT NULL AND g.rating_category IS NOT NULL
+					THEN COALESCE(ROUND(br.rating)::int, $1::int) END AS black_rating) r
+			WHERE g.finished_at IS NULL
+		) lobby
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + order + `
+		LIMIT ` + arg(filter.Limit+1)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get open games: %w", err)
+	}
+	defer rows.Close()
+
+	games := []LobbyGame{}
+	for rows.Next() {
+		var game LobbyGame
+		if err := rows.Scan(&game.ID, &game.PlayerWhite, &game.PlayerBlack, &game.WhiteUsername, &game.BlackUsername,
+			&game.TimeControl, &game.Rated, &game.CreatedAt, &game.WhiteRating, &game.BlackRating,
+			&game.WhiteProvisional, &game.BlackProvisional, &game.Rating); err != nil {
+			return nil, "", fmt.Errorf("failed to scan game: %w", err)
+		}
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to get open games: %w", err)
+	}
+
+	if len(games) <= filter.Limit {
+		return games, "", nil
+	}
+	games = games[:filter.Limit]
+	last := games[len(games)-1]
+	next := encodeCursor(pageCursor{Rating: last.Rating, Time: last.CreatedAt.Format(cursorTimestamp), ID: last.ID})
+	return games, next, nil
+}
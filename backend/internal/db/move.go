@@ -2,18 +2,93 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"gophermatebackend/internal/cache"
+)
+
+// Errors returned by SaveMove for games that do not take moves.
+var (
+	ErrGameFinished   = errors.New("game is already finished")
+	ErrGameNotStarted = errors.New("game is waiting for an opponent")
 )
 
-// SaveMove inserts a move into the moves table.
-// SaveMove inserts a move into the moves table. move_number is set by DB trigger.
-func SaveMove(dbConn *sql.DB, gameID string, playerID int64, notation string) error {
-	query := `INSERT INTO moves (game_id, player_id, notation) VALUES ($1, $2, $3)`
-	_, err := dbConn.Exec(query, gameID, playerID, notation)
+// Move is a row of the moves table.
+type Move struct {
+	Number    int
+	PlayerID  int64
+	Notation  string // SAN, or "white-pawn e2->e4" for rows saved before SAN support
+	UCI       string // "" for rows saved before SAN support
+	CreatedAt time.Time
+}
+
+// SaveMove inserts a move into the moves table with its SAN notation and UCI form, and stores the clock
+// after the move (see saveClock) in the same transaction. move_number is set by DB trigger.
+// Returns ErrGameFinished if the game has finished, even if its board is still cached, and
+// ErrGameNotStarted while a seat is still empty.
+func SaveMove(dbConn *sql.DB, gameID string, playerID int64, notation string, uci string, clock cache.Clock, sideToMove string) error {
+	tx, err := dbConn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save move: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The row lock keeps the game from finishing before the move is committed
+	var seated bool
+	query := `SELECT player_white_id IS NOT NULL AND player_black_id IS NOT NULL FROM games
+		WHERE id = $1 AND finished_at IS NULL FOR UPDATE`
+	err = tx.QueryRow(query, gameID).Scan(&seated)
+	if err == sql.ErrNoRows {
+		return ErrGameFinished
+	}
 	if err != nil {
 		return fmt.Errorf("failed to save move: %w", err)
 	}
+	if !seated {
+		return ErrGameNotStarted
+	}
+
+	query = `INSERT INTO moves (game_id, player_id, notation, uci) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(query, gameID, playerID, notation, uci); err != nil {
+		return fmt.Errorf("failed to save move: %w", err)
+	}
+	if err := saveClock(tx, gameID, clock, sideToMove); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save move: %w", err)
+	}
 	return nil
 }
 
+// GetMoves returns all moves of a game ordered by move number.
+func GetMoves(dbConn *sql.DB, gameID string) ([]Move, error) {
+	query := `SELECT move_number, player_id, notation, uci, created_at FROM moves WHERE game_id = $1 ORDER BY move_number, id`
+	rows, err := dbConn.Query(query, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moves: %w", err)
+	}
+	defer rows.Close()
+
+	var moves []Move
+	for rows.Next() {
+		var m Move
+		var number sql.NullInt64
+		var notation, uci sql.NullString
+		if err := rows.Scan(&number, &m.PlayerID, &notation, &uci, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan move: %w", err)
+		}
+		m.Number = int(number.Int64)
+		m.Notation = notation.String
+		m.UCI = uci.String
+		moves = append(moves, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate moves: %w", err)
+	}
+	return moves, nil
+}
+
 // No need for GetMoveCount; move_number is handled by DB trigger.
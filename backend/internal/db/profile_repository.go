@@ -0,0 +1,152 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Profile is the account information of a user with their record over finished games.
+type Profile struct {
+	ID        int64
+	Username  string
+	Email     string
+	CreatedAt time.Time
+	Wins      int
+	Losses    int
+	Draws     int
+}
+
+// GetProfile returns the profile of the user with the given username, or sql.ErrNoRows if there is none.
+func GetProfile(dbConn *sql.DB, username string) (*Profile, error) {
+	return getProfile(dbConn, "u.username = $1", username)
+}
+
+// GetProfileByID returns the profile of a user, or sql.ErrNoRows if there is none.
+func GetProfileByID(dbConn *sql.DB, userID int64) (*Profile, error) {
+	return getProfile(dbConn, "u.id = $1", userID)
+}
+
+func getProfile(dbConn *sql.DB, condition string, arg interface{}) (*Profile, error) {
+	query := `SELECT u.id, u.username, u.email, u.created_at,
+			COUNT(g.id) FILTER (WHERE (g.winner = 'white' AND g.player_white_id = u.id) OR (g.winner = 'black' AND g.player_black_id = u.id)),
+			COUNT(g.id) FILTER (WHERE (g.winner = 'white' AND g.player_black_id = u.id) OR (g.winner = 'black' AND g.player_white_id = u.id)),
+			COUNT(g.id) FILTER (WHERE g.winner = 'draw')
+		FROM users u
+		LEFT JOIN games g ON g.finished_at IS NOT NULL AND (g.player_white_id = u.id OR g.player_black_id = u.id)
+		WHERE ` + condition + `
+		GROUP BY u.id`
+	var p Profile
+	err := dbConn.QueryRow(query, arg).Scan(&p.ID, &p.Username, &p.Email, &p.CreatedAt, &p.Wins, &p.Losses, &p.Draws)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// HistoryFilter selects the finished games listed by GetUserGames, most recently finished first.
+type HistoryFilter struct {
+	Result   string    // "win", "loss", "draw" or "none" (no winner recorded) from the user's point of view, "" for any
+	Color    string    // color the user played, "white" or "black", "" for any
+	Opponent string    // opponent's username, "" for any
+	From     time.Time // only games finished at or after From, zero for no lower bound
+	To       time.Time // only games finished before To, zero for no upper bound
+	Cursor   string    // cursor returned with the previous page, "" for the first page
+	Limit    int       // page size, must be positive
+}
+
+// HistoryGame is a finished game seen from one of its players.
+type HistoryGame struct {
+	Game
+	Color        string
+	PlyCount     int             // number of half-moves played
+	RatingBefore sql.NullFloat64 // the player's rating before and after a rated game
+	RatingAfter  sql.NullFloat64
+}
+
+// GetUserGames returns a page of the finished games of a user matching the filter, and the cursor
+// of the next page ("" on the last page).
+func GetUserGames(dbConn *sql.DB, userID int64, filter HistoryFilter) ([]HistoryGame, string, error) {
+	args := []interface{}{userID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"TRUE"}
+	switch filter.Result {
+	case "win":
+		conditions = append(conditions, "winner = color")
+	case "loss":
+		conditions = append(conditions, "winner IN ('white', 'black') AND winner <> color")
+	case "draw":
+		conditions = append(conditions, "winner = 'draw'")
+	case "none":
+		conditions = append(conditions, "COALESCE(winner, '') = ''")
+	}
+	if filter.Color != "" {
+		conditions = append(conditions, "color = "+arg(filter.Color))
+	}
+	if filter.Opponent != "" {
+		conditions = append(conditions, "CASE WHEN color = 'white' THEN black_username ELSE white_username END = "+arg(filter.Opponent))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "finished_at >= "+arg(filter.From.Format(cursorTimestamp))+"::timestamp")
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "finished_at < "+arg(filter.To.Format(cursorTimestamp))+"::timestamp")
+	}
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, fmt.Sprintf("(finished_at, id) < (%s::timestamp, %s::uuid)", arg(cursor.Time), arg(cursor.ID)))
+	}
+
+	// One extra row tells whether there is a next page
+	query := `SELECT id, player_white_id, player_black_id, white_username, black_username, winner, result_reason,
+			time_control, rated, initial_fen, created_at, finished_at, color, ply_count, rating_before, rating_after
+		FROM (
+			SELECT g.id, g.player_white_id, g.player_black_id, w.username AS white_username, b.username AS black_username,
+				g.winner, g.result_reason, g.time_control, g.rated, g.initial_fen, g.created_at, g.finished_at,
+				CASE WHEN g.player_white_id = $1 THEN 'white' ELSE 'black' END AS color,
+				(SELECT COUNT(*) FROM moves m WHERE m.game_id = g.id) AS ply_count,
+				h.rating_before, h.rating_after
+			FROM games g
+			LEFT JOIN users w ON w.id = g.player_white_id
+			LEFT JOIN users b ON b.id = g.player_black_id
+			LEFT JOIN rating_history h ON h.game_id = g.id AND h.user_id = $1
+			WHERE g.finished_at IS NOT NULL AND (g.player_white_id = $1 OR g.player_black_id = $1)
+		) history
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY finished_at DESC, id DESC
+		LIMIT ` + arg(filter.Limit+1)
+	rows, err := dbConn.Query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get user games: %w", err)
+	}
+	defer rows.Close()
+
+	games := []HistoryGame{}
+	for rows.Next() {
+		var game HistoryGame
+		if err := rows.Scan(&game.ID, &game.PlayerWhite, &game.PlayerBlack, &game.WhiteUsername, &game.BlackUsername,
+			&game.Winner, &game.ResultReason, &game.TimeControl, &game.Rated, &game.InitialFEN, &game.CreatedAt,
+			&game.FinishedAt, &game.Color, &game.PlyCount, &game.RatingBefore, &game.RatingAfter); err != nil {
+			return nil, "", fmt.Errorf("failed to scan game: %w", err)
+		}
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to get user games: %w", err)
+	}
+
+	if len(games) <= filter.Limit {
+		return games, "", nil
+	}
+	games = games[:filter.Limit]
+	last := games[len(games)-1]
+	return games, encodeCursor(pageCursor{Time: last.FinishedAt.Time.Format(cursorTimestamp), ID: last.ID}), nil
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"gophermatebackend/internal/rating"
+)
+
+// DefaultRating is the rating of a player who has not played any rated game.
+const DefaultRating = int(rating.DefaultRating)
+
+// UserRating is the rating of a user in one rating pool.
+type UserRating struct {
+	Category    string
+	Rating      rating.Rating
+	GamesPlayed int
+}
+
+// Value returns the rating rounded to an integer, as shown to players.
+func (r UserRating) Value() int {
+	return int(math.Round(r.Rating.Rating))
+}
+
+// GetUserRating returns the rating of a user in a rating pool (see cache.TimeControl.Category),
+// or the default rating if the user has not played a rated game in the pool.
+func GetUserRating(dbConn *sql.DB, userID int64, category string) (UserRating, error) {
+	r := UserRating{Category: category, Rating: rating.Default()}
+	if category == "" {
+		return r, nil
+	}
+	query := `SELECT rating, deviation, volatility, games_played FROM ratings WHERE user_id = $1 AND category = $2`
+	err := dbConn.QueryRow(query, userID, category).Scan(&r.Rating.Rating, &r.Rating.Deviation, &r.Rating.Volatility, &r.GamesPlayed)
+	if err == sql.ErrNoRows {
+		return r, nil
+	}
+	if err != nil {
+		return r, fmt.Errorf("failed to get rating: %w", err)
+	}
+	return r, nil
+}
+
+// updateRatings rates a finished game for both players inside the transaction that finishes it.
+// winner is "white", "black" or "draw".
+func updateRatings(tx *sql.Tx, gameID string, whiteID int64, blackID int64, category string, winner string) error {
+	// Lock both rows, creating them for first rated games, so concurrent games of a player are rated one after the other
+	first, second := whiteID, blackID
+	if first > second {
+		first, second = second, first
+	}
+	def := rating.Default()
+	_, err := tx.Exec(`INSERT INTO ratings (user_id, category, rating, deviation, volatility)
+		VALUES ($1, $3, $4, $5, $6), ($2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+		first, second, category, def.Rating, def.Deviation, def.Volatility)
+	if err != nil {
+		return fmt.Errorf("failed to create ratings: %w", err)
+	}
+	rows, err := tx.Query(`SELECT user_id, rating, deviation, volatility FROM ratings
+		WHERE user_id IN ($1, $2) AND category = $3 ORDER BY user_id FOR UPDATE`, whiteID, blackID, category)
+	if err != nil {
+		return fmt.Errorf("failed to lock ratings: %w", err)
+	}
+	current := map[int64]rating.Rating{}
+	for rows.Next() {
+		var userID int64
+		var r rating.Rating
+		if err := rows.Scan(&userID, &r.Rating, &r.Deviation, &r.Volatility); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan rating: %w", err)
+		}
+		current[userID] = r
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock ratings: %w", err)
+	}
+
+	whiteScore := 0.5
+	switch winner {
+	case "white":
+		whiteScore = 1
+	case "black":
+		whiteScore = 0
+	}
+	white, black := current[whiteID], current[blackID]
+	updated := map[int64]rating.Rating{
+		whiteID: rating.Update(white, black, whiteScore),
+		blackID: rating.Update(black, white, 1-whiteScore),
+	}
+
+	for userID, r := range updated {
+		_, err := tx.Exec(`UPDATE ratings SET rating = $1, deviation = $2, volatility = $3,
+				games_played = games_played + 1, updated_at = NOW()
+			WHERE user_id = $4 AND category = $5`, r.Rating, r.Deviation, r.Volatility, userID, category)
+		if err != nil {
+			return fmt.Errorf("failed to update rating: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO rating_history (user_id, game_id, category, rating_before, rating_after, deviation_after)
+			VALUES ($1, $2, $3, $4, $5, $6)`, userID, gameID, category, current[userID].Rating, r.Rating, r.Deviation)
+		if err != nil {
+			return fmt.Errorf("failed to record rating history: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetUserRatings returns the ratings of a user in the pools where they played rated games.
+func GetUserRatings(dbConn *sql.DB, userID int64) ([]UserRating, error) {
+	query := `SELECT category, rating, deviation, volatility, games_played FROM ratings WHERE user_id = $1 ORDER BY category`
+	rows, err := dbConn.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ratings: %w", err)
+	}
+	defer rows.Close()
+
+	var ratings []UserRating
+	for rows.Next() {
+		var r UserRating
+		if err := rows.Scan(&r.Category, &r.Rating.Rating, &r.Rating.Deviation, &r.Rating.Volatility, &r.GamesPlayed); err != nil {
+			return nil, fmt.Errorf("failed to scan rating: %w", err)
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, rows.Err()
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"gophermatebackend/internal/cache"
+)
+
+// rematchable restricts an UPDATE of games to finished games between two players that have no rematch yet.
+const rematchable = `finished_at IS NOT NULL AND player_white_id IS NOT NULL AND player_black_id IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM games r WHERE r.rematch_of = games.id)`
+
+// OfferRematch records a rematch offer by color on a finished game.
+// Returns sql.ErrNoRows if the game cannot be rematched or the opponent already offered one.
+func OfferRematch(dbConn *sql.DB, gameID string, color string) error {
+	query := `UPDATE games SET rematch_offered_by = $1
+		WHERE id = $2 AND (rematch_offered_by IS NULL OR rematch_offered_by = $1) AND ` + rematchable
+	return expectOneRow(dbConn.Exec(query, color, gameID))
+}
+
+// DeclineRematch clears the rematch offer made by the opponent of color.
+// Returns sql.ErrNoRows if there is no such offer.
+func DeclineRematch(dbConn *sql.DB, gameID string, color string) error {
+	query := `UPDATE games SET rematch_offered_by = NULL WHERE id = $1 AND rematch_offered_by IS NOT NULL AND rematch_offered_by <> $2`
+	return expectOneRow(dbConn.Exec(query, gameID, color))
+}
+
+// AcceptRematch accepts the rematch offer made by the opponent of color and creates the new game
+// with the same time control, the same rated or casual mode and swapped colors. Returns sql.ErrNoRows if there is no such offer.
+func AcceptRematch(dbConn *sql.DB, gameID string, color string) (string, cache.TimeControl, error) {
+	tx, err := dbConn.Begin()
+	if err != nil {
+		return "", cache.TimeControl{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var whiteID, blackID int64
+	var timeControl sql.NullString
+	var rated bool
+	query := `UPDATE games SET rematch_offered_by = NULL
+		WHERE id = $1 AND rematch_offered_by IS NOT NULL AND rematch_offered_by <> $2 AND ` + rematchable + `
+		RETURNING player_white_id, player_black_id, time_control, rated`
+	if err := tx.QueryRow(query, gameID, color).Scan(&whiteID, &blackID, &timeControl, &rated); err != nil {
+		return "", cache.TimeControl{}, err
+	}
+	tc, err := cache.ParseTimeControl(timeControl.String)
+	if err != nil {
+		return "", cache.TimeControl{}, fmt.Errorf("invalid time control for game %s: %w", gameID, err)
+	}
+
+	rematchID, err := insertMatchedGame(tx, blackID, whiteID, tc, rated, gameID)
+	if err != nil {
+		return "", cache.TimeControl{}, fmt.Errorf("failed to create rematch: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", cache.TimeControl{}, fmt.Errorf("failed to commit rematch: %w", err)
+	}
+	return rematchID, tc, nil
+}
+
+// expectOneRow turns an UPDATE that changed no row into sql.ErrNoRows.
+func expectOneRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
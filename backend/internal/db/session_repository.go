@@ -2,60 +2,263 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"gophermatebackend/internal/cache"
+	"gophermatebackend/internal/utils"
 
 	"github.com/google/uuid"
 )
 
-func defaultExpirationTime() time.Time {
-	return time.Now().Add(24 * time.Hour)
+var (
+	// ErrSessionExpired is returned for a session token or refresh token that is past its expiry.
+	ErrSessionExpired = errors.New("session expired")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+	// The token has leaked, so the whole session is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// lastSeenInterval is how often the last seen time and sliding expiry of a session in use are written to the database.
+const lastSeenInterval = time.Minute
+
+var sessionConfig = sync.OnceValue(utils.LoadConfig)
+
+// accessExpirationTime is the expiry of a session token used now. Every use slides it forward.
+// Expiry times are stored as UTC in columns without time zone.
+func accessExpirationTime() time.Time {
+	return time.Now().UTC().Add(sessionConfig().AccessTokenTTL)
+}
+
+func refreshExpirationTime() time.Time {
+	return time.Now().UTC().Add(sessionConfig().RefreshTokenTTL)
+}
+
+// storedUTC reads a UTC time stored in a column without time zone.
+func storedUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
 }
 
-func CreateSession(userID int) (string, error) {
+// SessionTokens are the credentials handed to a client when it logs in or refreshes its session.
+// The access token authenticates requests; the refresh token is exchanged once for new tokens
+// through RefreshSession when the access token has expired.
+type SessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time // expiry of the access token, unless it is used before
+}
+
+// CreateSession starts a session for a user logging in from the given client and returns its tokens.
+func CreateSession(userID int, userAgent string, ipAddress string) (SessionTokens, error) {
 	db, err := InitDB()
 	if err != nil {
-		return "", err
+		return SessionTokens{}, err
 	}
 
-	sessionToken := uuid.New().String()
-	expiresAt := defaultExpirationTime()
-
-	query := "INSERT INTO sessions (token, user_id, expires_at) VALUES ($1, $2, $3)"
-	_, err = db.Exec(query, sessionToken, userID, expiresAt)
+	tx, err := db.Begin()
 	if err != nil {
-		return "", err
+		return SessionTokens{}, err
 	}
+	defer tx.Rollback()
 
-	return sessionToken, nil
+	tokens := SessionTokens{AccessToken: uuid.New().String(), RefreshToken: uuid.New().String(), ExpiresAt: accessExpirationTime()}
+	var sessionID int64
+	query := `INSERT INTO sessions (token, user_id, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5) RETURNING id`
+	if err := tx.QueryRow(query, tokens.AccessToken, userID, userAgent, ipAddress, tokens.ExpiresAt).Scan(&sessionID); err != nil {
+		return SessionTokens{}, err
+	}
+	if err := insertRefreshToken(tx, sessionID, tokens.RefreshToken); err != nil {
+		return SessionTokens{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return SessionTokens{}, err
+	}
+	return tokens, nil
 }
 
-// Refactored: now uses cache for session token lookup
+// GetUserIDBySessionToken returns the user of a session token, using the cache when possible.
+// Revoked and unknown tokens return sql.ErrNoRows, expired ones ErrSessionExpired.
+// Using a token extends its expiry by the access token lifetime.
 func GetUserIDBySessionToken(db *sql.DB, sessionToken string) (int64, error) {
 	// Check cache first
 	if userID, ok := cache.GetUserIDByToken(sessionToken); ok {
+		expiresAt := accessExpirationTime()
+		if cache.SessionSeen(sessionToken, lastSeenInterval, expiresAt) {
+			touchSession(db, sessionToken, expiresAt)
+		}
 		return userID, nil
 	}
-	// Not in cache or expired, query DB
+	// Not in cache or expired, query DB. Revoked sessions no longer authenticate.
 	var userID int64
 	var expiresAt time.Time
-	query := "SELECT user_id, expires_at FROM sessions WHERE token = $1"
+	query := "SELECT user_id, expires_at FROM sessions WHERE token = $1 AND revoked_at IS NULL"
 	row := db.QueryRow(query, sessionToken)
 	if err := row.Scan(&userID, &expiresAt); err != nil {
 		// On error, do not cache
 		return 0, err
 	}
-	// if the session is expired, delete the session from the cache and create a new one
-	if expiresAt.Before(time.Now()) {
+	if storedUTC(expiresAt).Before(time.Now()) {
 		cache.DeleteUserIDForToken(sessionToken)
-		var err error
-		sessionToken, err = CreateSession(int(userID))
-		if err != nil {
-			return 0, err
-		}
+		return 0, ErrSessionExpired
 	}
+	expiresAt = accessExpirationTime()
+	touchSession(db, sessionToken, expiresAt)
 	// Update cache
-	cache.SetUserIDForToken(sessionToken, userID, defaultExpirationTime())
+	cache.SetUserIDForToken(sessionToken, userID, expiresAt)
 	return userID, nil
 }
+
+// touchSession sets the last seen time of a session to now and slides its expiry.
+func touchSession(db *sql.DB, sessionToken string, expiresAt time.Time) {
+	query := "UPDATE sessions SET last_seen_at = NOW(), expires_at = $1 WHERE token = $2 AND revoked_at IS NULL"
+	if _, err := db.Exec(query, expiresAt, sessionToken); err != nil {
+		log.Printf("touchSession: Failed to update session: %v", err)
+	}
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new refresh token of the same session.
+// Each refresh token works once: presenting a rotated one again revokes the whole session and returns
+// ErrRefreshTokenReused. Returns ErrSessionExpired for an expired refresh token and sql.ErrNoRows for
+// an unknown one or a revoked session.
+func RefreshSession(db *sql.DB, refreshToken string) (SessionTokens, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return SessionTokens{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sessionID int64
+	var oldAccessToken string
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	query := `SELECT r.session_id, s.token, r.expires_at, r.used_at
+		FROM refresh_tokens r
+		JOIN sessions s ON s.id = r.session_id
+		WHERE r.token::text = $1 AND s.revoked_at IS NULL
+		FOR UPDATE`
+	if err := tx.QueryRow(query, refreshToken).Scan(&sessionID, &oldAccessToken, &expiresAt, &usedAt); err != nil {
+		return SessionTokens{}, err
+	}
+	if usedAt.Valid {
+		tx.Rollback()
+		if _, err := revokeSessions(db, "id = $1", sessionID); err != nil {
+			return SessionTokens{}, err
+		}
+		log.Printf("RefreshSession: Refresh token reused, revoked session %d", sessionID)
+		return SessionTokens{}, ErrRefreshTokenReused
+	}
+	if storedUTC(expiresAt).Before(time.Now()) {
+		return SessionTokens{}, ErrSessionExpired
+	}
+
+	tokens := SessionTokens{AccessToken: uuid.New().String(), RefreshToken: uuid.New().String(), ExpiresAt: accessExpirationTime()}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = NOW() WHERE token::text = $1`, refreshToken); err != nil {
+		return SessionTokens{}, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if err := insertRefreshToken(tx, sessionID, tokens.RefreshToken); err != nil {
+		return SessionTokens{}, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE sessions SET token = $1, expires_at = $2, last_seen_at = NOW() WHERE id = $3`,
+		tokens.AccessToken, tokens.ExpiresAt, sessionID)
+	if err != nil {
+		return SessionTokens{}, fmt.Errorf("failed to rotate session token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return SessionTokens{}, fmt.Errorf("failed to commit refresh: %w", err)
+	}
+	cache.DeleteUserIDForToken(oldAccessToken)
+	return tokens, nil
+}
+
+func insertRefreshToken(tx *sql.Tx, sessionID int64, refreshToken string) error {
+	query := `INSERT INTO refresh_tokens (token, session_id, expires_at) VALUES ($1, $2, $3)`
+	_, err := tx.Exec(query, refreshToken, sessionID, refreshExpirationTime())
+	return err
+}
+
+// Session is an active login of a user. The token itself is never listed.
+type Session struct {
+	ID         int64
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	Current    bool // the session of the request listing the sessions
+}
+
+// GetUserSessions returns the unrevoked, unexpired sessions of a user, most recently used first.
+// currentToken marks the session making the request.
+func GetUserSessions(db *sql.DB, userID int64, currentToken string) ([]Session, error) {
+	query := `SELECT id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, COALESCE(last_seen_at, created_at),
+			expires_at, token::text = $2
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $3
+		ORDER BY last_seen_at DESC NULLS LAST, id DESC`
+	rows, err := db.Query(query, userID, currentToken, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.Current); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		s.ExpiresAt = storedUTC(s.ExpiresAt)
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession logs a session token out. Revoking an unknown or already revoked token does nothing.
+func RevokeSession(db *sql.DB, sessionToken string) error {
+	_, err := revokeSessions(db, "token::text = $1", sessionToken)
+	return err
+}
+
+// RevokeSessionByID logs out one of the user's sessions.
+// Returns sql.ErrNoRows if the user has no such active session.
+func RevokeSessionByID(db *sql.DB, userID int64, sessionID int64) error {
+	count, err := revokeSessions(db, "user_id = $1 AND id = $2", userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RevokeUserSessions logs out every session of a user and returns how many were active.
+func RevokeUserSessions(db *sql.DB, userID int64) (int, error) {
+	return revokeSessions(db, "user_id = $1", userID)
+}
+
+// revokeSessions sets revoked_at on the unrevoked sessions matching the condition and drops their
+// tokens from the cache, so they stop authenticating right away.
+func revokeSessions(db *sql.DB, condition string, args ...interface{}) (int, error) {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE revoked_at IS NULL AND ` + condition + ` RETURNING token`
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return count, fmt.Errorf("failed to scan session: %w", err)
+		}
+		cache.DeleteUserIDForToken(token)
+		count++
+	}
+	return count, rows.Err()
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"sync"
+
+	"gophermatebackend/internal/utils"
+)
+
+// Event types pushed to game subscribers.
+const (
+	TypeMove         = "move"
+	TypeJoined       = "joined"
+	TypeDrawOffer    = "draw_offer"
+	TypeDrawDeclined = "draw_declined"
+	TypeResigned     = "resigned"
+	TypeFinished     = "finished"
+
+	TypeRematchOffer    = "rematch_offer"
+	TypeRematchDeclined = "rematch_declined"
+	TypeRematchAccepted = "rematch_accepted"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind before events are dropped.
+const subscriberBuffer = 16
+
+// Event is something that happened in a game, pushed to every subscriber of that game.
+type Event struct {
+	Type   string                 `json:"type"`
+	GameID string                 `json:"game_id"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+// subscribers is the in-process pub/sub hub: game ID to the set of subscriber channels.
+var (
+	subscribers   = make(map[string]map[chan Event]struct{})
+	subscribersMu sync.RWMutex
+)
+
+// Subscribe registers a listener for the events of a game. The returned function unsubscribes
+// and closes the channel; it must be called when the listener goes away.
+func Subscribe(gameID string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	subscribersMu.Lock()
+	if subscribers[gameID] == nil {
+		subscribers[gameID] = make(map[chan Event]struct{})
+	}
+	subscribers[gameID][ch] = struct{}{}
+	subscribersMu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			delete(subscribers[gameID], ch)
+			if len(subscribers[gameID]) == 0 {
+				delete(subscribers, gameID)
+			}
+			subscribersMu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish sends an event to all subscribers of a game. It never blocks: a subscriber whose
+// buffer is full misses the event and is expected to resync from the game state.
+func Publish(gameID string, event Event) {
+	event.GameID = gameID
+	subscribersMu.RLock()
+	defer subscribersMu.RUnlock()
+	for ch := range subscribers[gameID] {
+		select {
+		case ch <- event:
+		default:
+			utils.LogWarning("events.Publish: dropping " + event.Type + " event for a slow subscriber of game " + gameID)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package movevalidation
+
+import (
+	"errors"
+	"gophermatebackend/internal/cache"
+)
+
+// IsCastling reports whether the move is a king moving two squares sideways from its starting square.
+func IsCastling(move MoveData) bool {
+	if !isPiece(move.Piece, "king") {
+		return false
+	}
+	homeRow := 7
+	if getColor(move.Piece) == "black" {
+		homeRow = 0
+	}
+	return move.From.Row == homeRow && move.From.Col == 4 && move.To.Row == homeRow && abs(move.To.Col-move.From.Col) == 2
+}
+
+// CastlingNotation returns "O-O" for king-side and "O-O-O" for queen-side castling.
+func CastlingNotation(move MoveData) string {
+	if move.To.Col > move.From.Col {
+		return "O-O"
+	}
+	return "O-O-O"
+}
+
+// validateCastling validates king-side and queen-side castling: the right must still be available,
+// the rook must be in place, the path must be empty and the king may not castle out of,
+// through or into check.
+func validateCastling(board *cache.Board, move MoveData) (bool, error) {
+	color := getColor(move.Piece)
+	kingSide := move.To.Col > move.From.Col
+	if !hasCastlingRight(board.Castling, color, kingSide) {
+		return false, errors.New("Castling is no longer allowed")
+	}
+	rookFrom, _ := castlingRookSquares(move)
+	if board.Squares[rookFrom.Row][rookFrom.Col] != color+"-rook" {
+		return false, errors.New("Castling requires the rook on its starting square")
+	}
+	step := 1
+	if !kingSide {
+		step = -1
+	}
+	for c := move.From.Col + step; c != rookFrom.Col; c += step {
+		if board.Squares[move.From.Row][c] != "" {
+			return false, errors.New("Castling path is not empty")
+		}
+	}
+	opponent := opponentColor(color)
+	for c := move.From.Col; c != move.To.Col+step; c += step {
+		if IsSquareAttacked(board, Position{Row: move.From.Row, Col: c}, opponent) {
+			return false, errors.New("King cannot castle out of, through or into check")
+		}
+	}
+	return true, nil
+}
+
+// castlingRookSquares returns the rook's from and to squares for a castling move.
+func castlingRookSquares(move MoveData) (Position, Position) {
+	row := move.From.Row
+	if move.To.Col > move.From.Col {
+		return Position{Row: row, Col: 7}, Position{Row: row, Col: 5}
+	}
+	return Position{Row: row, Col: 0}, Position{Row: row, Col: 3}
+}
+
+func hasCastlingRight(rights cache.CastlingRights, color string, kingSide bool) bool {
+	switch {
+	case color == "white" && kingSide:
+		return rights.WhiteKingSide
+	case color == "white":
+		return rights.WhiteQueenSide
+	case kingSide:
+		return rights.BlackKingSide
+	default:
+		return rights.BlackQueenSide
+	}
+}
+
+// updateCastlingRights clears rights when a king moves, or when a move starts or ends on a rook's starting square.
+func updateCastlingRights(board *cache.Board, move MoveData) {
+	switch move.Piece {
+	case "white-king":
+		board.Castling.WhiteKingSide = false
+		board.Castling.WhiteQueenSide = false
+	case "black-king":
+		board.Castling.BlackKingSide = false
+		board.Castling.BlackQueenSide = false
+	}
+	for _, pos := range []Position{move.From, move.To} {
+		switch pos {
+		case Position{Row: 7, Col: 7}:
+			board.Castling.WhiteKingSide = false
+		case Position{Row: 7, Col: 0}:
+			board.Castling.WhiteQueenSide = false
+		case Position{Row: 0, Col: 7}:
+			board.Castling.BlackKingSide = false
+		case Position{Row: 0, Col: 0}:
+			board.Castling.BlackQueenSide = false
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package movevalidation
+
+import (
+	"testing"
+
+	"gophermatebackend/internal/cache"
+)
+
+func castlingBoard() *cache.Board {
+	board := boardWith("black", map[string]string{
+		"e1": "white-king",
+		"a1": "white-rook",
+		"h1": "white-rook",
+		"e8": "black-king",
+	})
+	board.Castling = cache.CastlingRights{WhiteKingSide: true, WhiteQueenSide: true}
+	return board
+}
+
+func TestCastlingMovesKingAndRook(t *testing.T) {
+	for _, tc := range []struct {
+		to, rookFrom, rookTo string
+	}{
+		{"g1", "h1", "f1"},
+		{"c1", "a1", "d1"},
+	} {
+		board := castlingBoard()
+		castle := pieceMove("white-king", "e1", tc.to)
+		if ok, err := ValidateMove(board, castle); !ok {
+			t.Errorf("castling to %s rejected: %v", tc.to, err)
+			continue
+		}
+		ApplyMove(board, castle)
+		rookFrom, rookTo := sq(tc.rookFrom), sq(tc.rookTo)
+		if board.Squares[rookTo.Row][rookTo.Col] != "white-rook" || board.Squares[rookFrom.Row][rookFrom.Col] != "" {
+			t.Errorf("castling to %s left the rook on %s", tc.to, tc.rookFrom)
+		}
+		if board.Castling.WhiteKingSide || board.Castling.WhiteQueenSide {
+			t.Errorf("castling to %s kept white's castling rights", tc.to)
+		}
+	}
+}
+
+func TestCastlingRejected(t *testing.T) {
+	for name, tc := range map[string]struct {
+		pieces map[string]string
+		rights cache.CastlingRights
+	}{
+		"right lost":      {nil, cache.CastlingRights{WhiteQueenSide: true}},
+		"path blocked":    {map[string]string{"g1": "white-knight"}, cache.CastlingRights{WhiteKingSide: true}},
+		"out of check":    {map[string]string{"e5": "black-rook"}, cache.CastlingRights{WhiteKingSide: true}},
+		"through check":   {map[string]string{"f5": "black-rook"}, cache.CastlingRights{WhiteKingSide: true}},
+		"into check":      {map[string]string{"g5": "black-rook"}, cache.CastlingRights{WhiteKingSide: true}},
+		"rook is missing": {map[string]string{"h1": ""}, cache.CastlingRights{WhiteKingSide: true}},
+	} {
+		board := castlingBoard()
+		board.Castling = tc.rights
+		for square, piece := range tc.pieces {
+			pos := sq(square)
+			board.Squares[pos.Row][pos.Col] = piece
+		}
+		if ok, _ := ValidateMove(board, pieceMove("white-king", "e1", "g1")); ok {
+			t.Errorf("%s: king-side castling accepted", name)
+		}
+	}
+}
+
+func TestCastlingRightsLost(t *testing.T) {
+	for name, tc := range map[string]struct {
+		move MoveData
+		want cache.CastlingRights
+	}{
+		"king moves":       {pieceMove("white-king", "e1", "f1"), cache.CastlingRights{}},
+		"rook moves":       {pieceMove("white-rook", "a1", "a2"), cache.CastlingRights{WhiteKingSide: true}},
+		"rook is captured": {pieceMove("black-bishop", "c6", "h1"), cache.CastlingRights{WhiteQueenSide: true}},
+	} {
+		board := castlingBoard()
+		board.Squares[2][2] = "black-bishop"
+		ApplyMove(board, tc.move)
+		if board.Castling != tc.want {
+			t.Errorf("%s: rights = %+v, want %+v", name, board.Castling, tc.want)
+		}
+	}
+}
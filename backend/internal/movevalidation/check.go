@@ -0,0 +1,138 @@
+package movevalidation
+
+import "gophermatebackend/internal/cache"
+
+var (
+	knightOffsets    = [8][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+	kingOffsets      = [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+	rookDirections   = [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	bishopDirections = [4][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+)
+
+// ApplyMove updates the board for a move that has already been validated and records the mover in LastMove.
+// Castling also relocates the rook, en passant removes the captured pawn and promotion replaces the pawn.
+// Castling rights are updated for king and rook moves or captures, and the en passant target is only
+// kept for the ply after a double push. The halfmove clock and fullmove number advance as in FEN.
+func ApplyMove(board *cache.Board, move MoveData) {
+	capture := board.Squares[move.To.Row][move.To.Col] != "" || IsEnPassant(board, move)
+	if capture || isPiece(move.Piece, "pawn") {
+		board.HalfmoveClock = 0
+	} else {
+		board.HalfmoveClock++
+	}
+	if getColor(move.Piece) == "black" {
+		board.FullmoveNumber++
+	}
+	if IsCastling(move) {
+		rookFrom, rookTo := castlingRookSquares(move)
+		board.Squares[rookTo.Row][rookTo.Col] = board.Squares[rookFrom.Row][rookFrom.Col]
+		board.Squares[rookFrom.Row][rookFrom.Col] = ""
+	}
+	if IsEnPassant(board, move) {
+		// The captured pawn sits next to the from square, not on the target square
+		board.Squares[move.From.Row][move.To.Col] = ""
+	}
+	updateCastlingRights(board, move)
+	board.EnPassant = enPassantTarget(move)
+	board.Squares[move.To.Row][move.To.Col] = placedPiece(move)
+	board.Squares[move.From.Row][move.From.Col] = ""
+	board.LastMove = getColor(move.Piece)
+}
+
+// IsInCheck reports whether the king of the given color is attacked by the opponent.
+func IsInCheck(board *cache.Board, color string) bool {
+	king, ok := findKing(board, color)
+	if !ok {
+		return false
+	}
+	return IsSquareAttacked(board, king, opponentColor(color))
+}
+
+// IsSquareAttacked reports whether any piece of byColor attacks the given square.
+func IsSquareAttacked(board *cache.Board, pos Position, byColor string) bool {
+	// Pawns attack diagonally forward, so look one row behind the square from the attacker's point of view
+	pawnRow := pos.Row + 1
+	if byColor == "black" {
+		pawnRow = pos.Row - 1
+	}
+	for _, dc := range []int{-1, 1} {
+		if pieceAt(board, pawnRow, pos.Col+dc) == byColor+"-pawn" {
+			return true
+		}
+	}
+	for _, o := range knightOffsets {
+		if pieceAt(board, pos.Row+o[0], pos.Col+o[1]) == byColor+"-knight" {
+			return true
+		}
+	}
+	for _, o := range kingOffsets {
+		if pieceAt(board, pos.Row+o[0], pos.Col+o[1]) == byColor+"-king" {
+			return true
+		}
+	}
+	for _, d := range rookDirections {
+		piece := firstPieceInDirection(board, pos, d)
+		if piece == byColor+"-rook" || piece == byColor+"-queen" {
+			return true
+		}
+	}
+	for _, d := range bishopDirections {
+		piece := firstPieceInDirection(board, pos, d)
+		if piece == byColor+"-bishop" || piece == byColor+"-queen" {
+			return true
+		}
+	}
+	return false
+}
+
+// leavesKingInCheck plays the move on a copy of the board and checks the mover's king.
+func leavesKingInCheck(board *cache.Board, move MoveData) bool {
+	next := *board
+	ApplyMove(&next, move)
+	return IsInCheck(&next, getColor(move.Piece))
+}
+
+// findKing returns the position of the king of the given color.
+func findKing(board *cache.Board, color string) (Position, bool) {
+	for r := 0; r < 8; r++ {
+		for c := 0; c < 8; c++ {
+			if board.Squares[r][c] == color+"-king" {
+				return Position{Row: r, Col: c}, true
+			}
+		}
+	}
+	return Position{}, false
+}
+
+// firstPieceInDirection walks from pos (exclusive) in the given direction and returns the first piece found.
+func firstPieceInDirection(board *cache.Board, pos Position, dir [2]int) string {
+	r, c := pos.Row+dir[0], pos.Col+dir[1]
+	for r >= 0 && r < 8 && c >= 0 && c < 8 {
+		if board.Squares[r][c] != "" {
+			return board.Squares[r][c]
+		}
+		r += dir[0]
+		c += dir[1]
+	}
+	return ""
+}
+
+// pieceAt returns the piece on the square, or "" if empty or off the board.
+func pieceAt(board *cache.Board, row, col int) string {
+	if row < 0 || row > 7 || col < 0 || col > 7 {
+		return ""
+	}
+	return board.Squares[row][col]
+}
+
+func onBoard(pos Position) bool {
+	return pos.Row >= 0 && pos.Row < 8 && pos.Col >= 0 && pos.Col < 8
+}
+
+// opponentColor returns the other side's color.
+func opponentColor(color string) string {
+	if color == "white" {
+		return "black"
+	}
+	return "white"
+}
@@ -0,0 +1,83 @@
+package movevalidation
+
+import (
+	"testing"
+
+	"gophermatebackend/internal/cache"
+)
+
+// boardWith returns a board holding only the given pieces, keyed by square name (e.g., "e1").
+// lastMove is the color that just played, so the other color is to move.
+func boardWith(lastMove string, pieces map[string]string) *cache.Board {
+	board := &cache.Board{LastMove: lastMove}
+	for square, piece := range pieces {
+		pos := sq(square)
+		board.Squares[pos.Row][pos.Col] = piece
+	}
+	return board
+}
+
+// sq converts a square name to a board position, row 0 being the 8th rank.
+func sq(square string) Position {
+	return Position{Row: int('8' - square[1]), Col: int(square[0] - 'a')}
+}
+
+func pieceMove(piece string, from string, to string) MoveData {
+	return MoveData{Piece: piece, From: sq(from), To: sq(to)}
+}
+
+func TestValidateMoveRejectsMovingPinnedPiece(t *testing.T) {
+	board := boardWith("black", map[string]string{
+		"e1": "white-king",
+		"e2": "white-knight",
+		"e8": "black-rook",
+		"a8": "black-king",
+	})
+	if ok, _ := ValidateMove(board, pieceMove("white-knight", "e2", "c3")); ok {
+		t.Error("pinned knight moved off the e-file")
+	}
+	if ok, err := ValidateMove(board, pieceMove("white-king", "e1", "d1")); !ok {
+		t.Errorf("king step out of the pin rejected: %v", err)
+	}
+}
+
+func TestValidateMoveRejectsKingIntoCheck(t *testing.T) {
+	board := boardWith("black", map[string]string{
+		"e1": "white-king",
+		"d8": "black-rook",
+		"f3": "black-pawn",
+		"a8": "black-king",
+	})
+	for _, to := range []string{"d1", "d2", "e2"} {
+		if ok, _ := ValidateMove(board, pieceMove("white-king", "e1", to)); ok {
+			t.Errorf("king moved into check on %s", to)
+		}
+	}
+	if ok, err := ValidateMove(board, pieceMove("white-king", "e1", "f2")); !ok {
+		t.Errorf("king move to f2 rejected: %v", err)
+	}
+}
+
+func TestValidateMoveRequiresAnsweringCheck(t *testing.T) {
+	board := boardWith("black", map[string]string{
+		"e1": "white-king",
+		"a2": "white-pawn",
+		"d1": "white-rook",
+		"e8": "black-rook",
+		"a8": "black-king",
+	})
+	if !IsInCheck(board, "white") {
+		t.Fatal("white king on e1 is not reported in check from e8")
+	}
+	if ok, _ := ValidateMove(board, pieceMove("white-pawn", "a2", "a3")); ok {
+		t.Error("move ignoring the check accepted")
+	}
+	if ok, err := ValidateMove(board, pieceMove("white-rook", "d1", "d8")); ok {
+		t.Error("rook move that does not block accepted")
+	} else if err == nil || err.Error() != "Move would leave king in check" {
+		t.Errorf("got error %v, want Move would leave king in check", err)
+	}
+	if ok, err := ValidateMove(board, pieceMove("white-king", "e1", "f2")); !ok {
+		t.Errorf("king escape rejected: %v", err)
+	}
+}
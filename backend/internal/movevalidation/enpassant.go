@@ -0,0 +1,36 @@
+package movevalidation
+
+import "gophermatebackend/internal/cache"
+
+// IsEnPassant reports whether the move is a pawn capturing en passant onto board.EnPassant.
+func IsEnPassant(board *cache.Board, move MoveData) bool {
+	if !isPiece(move.Piece, "pawn") || board.EnPassant == "" || board.EnPassant != SquareName(move.To) {
+		return false
+	}
+	if abs(move.To.Col-move.From.Col) != 1 || board.Squares[move.To.Row][move.To.Col] != "" {
+		return false
+	}
+	captured := board.Squares[move.From.Row][move.To.Col]
+	return isPiece(captured, "pawn") && isOpponentPiece(captured, getColor(move.Piece))
+}
+
+// enPassantTarget returns the square skipped by a pawn double push, or "" for any other move.
+func enPassantTarget(move MoveData) string {
+	if !isPiece(move.Piece, "pawn") || abs(move.To.Row-move.From.Row) != 2 {
+		return ""
+	}
+	return SquareName(Position{Row: (move.From.Row + move.To.Row) / 2, Col: move.From.Col})
+}
+
+// SquareName converts a board position to algebraic notation (row 0 is rank 8, col 0 is file a).
+func SquareName(pos Position) string {
+	return string(rune('a'+pos.Col)) + string(rune('1'+(7-pos.Row)))
+}
+
+// ParseSquare converts algebraic notation such as "e4" to a board position.
+func ParseSquare(square string) (Position, bool) {
+	if len(square) != 2 || square[0] < 'a' || square[0] > 'h' || square[1] < '1' || square[1] > '8' {
+		return Position{}, false
+	}
+	return Position{Row: 7 - int(square[1]-'1'), Col: int(square[0] - 'a')}, true
+}
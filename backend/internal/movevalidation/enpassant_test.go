@@ -0,0 +1,59 @@
+package movevalidation
+
+import "testing"
+
+func TestEnPassantCapture(t *testing.T) {
+	board := boardWith("white", map[string]string{
+		"e1": "white-king",
+		"e5": "white-pawn",
+		"d7": "black-pawn",
+		"e8": "black-king",
+	})
+	ApplyMove(board, pieceMove("black-pawn", "d7", "d5"))
+	if board.EnPassant != "d6" {
+		t.Fatalf("EnPassant = %q after d5, want d6", board.EnPassant)
+	}
+
+	capture := pieceMove("white-pawn", "e5", "d6")
+	if ok, err := ValidateMove(board, capture); !ok {
+		t.Fatalf("exd6 rejected: %v", err)
+	}
+	ApplyMove(board, capture)
+	if board.Squares[3][3] != "" {
+		t.Errorf("captured pawn still on d5: %q", board.Squares[3][3])
+	}
+	if board.Squares[2][3] != "white-pawn" || board.EnPassant != "" {
+		t.Errorf("d6 = %q, EnPassant = %q after exd6", board.Squares[2][3], board.EnPassant)
+	}
+}
+
+func TestEnPassantOnlyRightAfterDoublePush(t *testing.T) {
+	board := boardWith("white", map[string]string{
+		"e1": "white-king",
+		"e5": "white-pawn",
+		"h2": "white-pawn",
+		"d7": "black-pawn",
+		"e8": "black-king",
+	})
+	ApplyMove(board, pieceMove("black-pawn", "d7", "d5"))
+	ApplyMove(board, pieceMove("white-pawn", "h2", "h3"))
+	ApplyMove(board, pieceMove("black-king", "e8", "f8"))
+	if ok, _ := ValidateMove(board, pieceMove("white-pawn", "e5", "d6")); ok {
+		t.Error("en passant accepted a move too late")
+	}
+}
+
+func TestEnPassantRejectedWhenItExposesTheKing(t *testing.T) {
+	// Both pawns leave the fifth rank, opening it to the rook
+	board := boardWith("white", map[string]string{
+		"a5": "white-king",
+		"e5": "white-pawn",
+		"d7": "black-pawn",
+		"h5": "black-rook",
+		"e8": "black-king",
+	})
+	ApplyMove(board, pieceMove("black-pawn", "d7", "d5"))
+	if ok, _ := ValidateMove(board, pieceMove("white-pawn", "e5", "d6")); ok {
+		t.Error("en passant exposing the king accepted")
+	}
+}
@@ -11,18 +11,45 @@ type Position struct {
 }
 
 type MoveData struct {
-	Piece string
-	From  Position
-	To    Position
+	Piece     string
+	From      Position
+	To        Position
+	Promotion string // "queen", "rook", "bishop" or "knight" when a pawn reaches the last rank, "" otherwise
 }
 
-// ValidateMove is the entrypoint for move validation. It dispatches to the correct piece validator.
+// ValidateMove is the entrypoint for move validation. It dispatches to the correct piece validator
+// and then rejects any move that would leave the mover's king in check.
 func ValidateMove(board *cache.Board, move MoveData) (bool, error) {
 	// check if the move is from the opposing player
+	if getColor(move.Piece) == "" {
+		return false, errors.New("Unknown piece type")
+	}
 	if (board.LastMove == "white" && move.Piece[:5] == "white") ||
 		(board.LastMove == "black" && move.Piece[:5] == "black") {
 		return false, errors.New("it's not your turn")
 	}
+	if !onBoard(move.From) || !onBoard(move.To) {
+		return false, errors.New("Move is outside the board")
+	}
+	if board.Squares[move.From.Row][move.From.Col] != move.Piece {
+		return false, errors.New("Piece is not on the from square")
+	}
+	if err := validatePromotion(move); err != nil {
+		return false, err
+	}
+	valid, err := validatePieceMove(board, move)
+	if !valid {
+		return false, err
+	}
+	// Final legality gate: the mover's own king must not be attacked afterwards
+	if leavesKingInCheck(board, move) {
+		return false, errors.New("Move would leave king in check")
+	}
+	return true, nil
+}
+
+// validatePieceMove checks the movement geometry of the piece, without considering checks.
+func validatePieceMove(board *cache.Board, move MoveData) (bool, error) {
 	switch {
 	case isPiece(move.Piece, "pawn"):
 		return validatePawnMove(board, move)
@@ -45,8 +72,11 @@ func isPiece(piece, name string) bool {
 	return piece == name || piece == "white-"+name || piece == "black-"+name
 }
 
-// validateKingMove validates king moves (one square in any direction, no castling yet)
+// validateKingMove validates king moves (one square in any direction, or castling)
 func validateKingMove(board *cache.Board, move MoveData) (bool, error) {
+	if IsCastling(move) {
+		return validateCastling(board, move)
+	}
 	deltaRow := abs(move.To.Row - move.From.Row)
 	deltaCol := abs(move.To.Col - move.From.Col)
 	if (deltaRow <= 1 && deltaCol <= 1) && (deltaRow != 0 || deltaCol != 0) {
@@ -157,7 +187,7 @@ func getColor(piece string) string {
 	return ""
 }
 
-// validatePawnMove validates pawn moves (basic forward, capture, double move, no en passant yet)
+// validatePawnMove validates pawn moves (basic forward, capture, double move, en passant)
 func validatePawnMove(board *cache.Board, move MoveData) (bool, error) {
 	// White pawns move up (row decreases), black pawns move down (row increases)
 	rowDir := 1
@@ -196,6 +226,9 @@ func validatePawnMove(board *cache.Board, move MoveData) (bool, error) {
 		if target != "" && isOpponentPiece(target, myColor) {
 			return true, nil
 		}
+		if IsEnPassant(board, move) {
+			return true, nil
+		}
 		return false, errors.New("Pawn capture must target opponent piece")
 	}
 
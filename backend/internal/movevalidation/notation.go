@@ -0,0 +1,186 @@
+package movevalidation
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"gophermatebackend/internal/cache"
+)
+
+var (
+	uciPattern    = regexp.MustCompile(`^([a-h][1-8])([a-h][1-8])([qrbn]?)$`)
+	legacyPattern = regexp.MustCompile(`^((?:white|black)-[a-z]+) ([a-h][1-8])->([a-h][1-8])(?:=([QRBN]))?(?: O-O(?:-O)?)?$`)
+	sanSuffixes   = regexp.MustCompile(`[+#!?]+$`)
+	looseSAN      = regexp.MustCompile(`^([KQRBN])?([a-h])?([1-8])?x?([a-h][1-8])(?:=?([QRBN]))?$`)
+)
+
+var promotionByLetter = map[string]string{"q": "queen", "r": "rook", "b": "bishop", "n": "knight"}
+
+// SAN returns the Standard Algebraic Notation of a legal move, computed on the board before the move
+// is applied (e.g., "Nbd7", "exd5", "O-O", "e8=Q+", "Qh4#").
+func SAN(board *cache.Board, move MoveData) string {
+	san := sanWithoutSuffix(board, move, LegalMoves(board))
+	next := *board
+	ApplyMove(&next, move)
+	if IsInCheck(&next, SideToMove(&next)) {
+		if GameStatus(&next) == StatusCheckmate {
+			return san + "#"
+		}
+		return san + "+"
+	}
+	return san
+}
+
+// UCI returns the long algebraic notation of a move as used by UCI engines (e.g., "e2e4", "e7e8q").
+func UCI(move MoveData) string {
+	uci := SquareName(move.From) + SquareName(move.To)
+	if move.Promotion != "" {
+		uci += strings.ToLower(pieceLetter(move.Promotion))
+	}
+	return uci
+}
+
+// ParseMove parses a move given in UCI (e.g., "e2e4") or SAN (e.g., "Nf3", "O-O") notation
+// and returns the matching move for the side to move. The move is not validated against check rules
+// when given in UCI; callers still run ValidateMove.
+func ParseMove(board *cache.Board, text string) (MoveData, error) {
+	text = strings.TrimSpace(text)
+	if m := uciPattern.FindStringSubmatch(text); m != nil {
+		return parseCoordinates(board, m[1], m[2], promotionByLetter[m[3]])
+	}
+	return parseSAN(board, text)
+}
+
+// ParseStoredMove reads a row of the moves table. Newer rows carry a UCI move, older rows only have
+// the legacy "white-pawn e2->e4" notation, and anything else is read as SAN.
+func ParseStoredMove(board *cache.Board, notation string, uci string) (MoveData, error) {
+	if uci != "" {
+		return ParseMove(board, uci)
+	}
+	if m := legacyPattern.FindStringSubmatch(notation); m != nil {
+		move, err := parseCoordinates(board, m[2], m[3], promotionByLetter[strings.ToLower(m[4])])
+		if err != nil {
+			return MoveData{}, err
+		}
+		if move.Piece != m[1] {
+			return MoveData{}, errors.New("Piece is not on the from square")
+		}
+		return move, nil
+	}
+	return parseSAN(board, notation)
+}
+
+// isLegacyNotation reports whether a row of the moves table was recorded in the legacy notation.
+func isLegacyNotation(notation string, uci string) bool {
+	return uci == "" && legacyPattern.MatchString(notation)
+}
+
+// parseCoordinates builds a move from two algebraic squares, taking the piece from the board.
+func parseCoordinates(board *cache.Board, from string, to string, promotion string) (MoveData, error) {
+	fromPos, ok := ParseSquare(from)
+	if !ok {
+		return MoveData{}, errors.New("Invalid from square")
+	}
+	toPos, ok := ParseSquare(to)
+	if !ok {
+		return MoveData{}, errors.New("Invalid to square")
+	}
+	piece := board.Squares[fromPos.Row][fromPos.Col]
+	if piece == "" {
+		return MoveData{}, errors.New("No piece on the from square")
+	}
+	return MoveData{Piece: piece, From: fromPos, To: toPos, Promotion: promotion}, nil
+}
+
+// parseSAN finds the legal move whose SAN matches the text. Check marks and annotations are ignored,
+// "0-0" is accepted for castling, and over-disambiguated moves such as "Ng1f3" or "e7e8Q" are accepted
+// as long as they match exactly one legal move.
+func parseSAN(board *cache.Board, text string) (MoveData, error) {
+	wanted := strings.TrimSuffix(strings.TrimSpace(text), "e.p.")
+	wanted = strings.ReplaceAll(sanSuffixes.ReplaceAllString(wanted, ""), "0", "O")
+	if wanted == "" {
+		return MoveData{}, errors.New("Empty move")
+	}
+	legal := LegalMoves(board)
+	for _, move := range legal {
+		if sanWithoutSuffix(board, move, legal) == wanted {
+			return move, nil
+		}
+	}
+
+	m := looseSAN.FindStringSubmatch(wanted)
+	if m == nil {
+		return MoveData{}, errors.New("Illegal or unrecognized move: " + text)
+	}
+	pieceType := "pawn"
+	for _, name := range []string{"king", "queen", "rook", "bishop", "knight"} {
+		if pieceLetter(name) == m[1] {
+			pieceType = name
+		}
+	}
+	var found []MoveData
+	for _, move := range legal {
+		from := SquareName(move.From)
+		switch {
+		case !isPiece(move.Piece, pieceType), SquareName(move.To) != m[4]:
+		case m[2] != "" && from[:1] != m[2], m[3] != "" && from[1:] != m[3]:
+		case m[5] != "" && move.Promotion != promotionByLetter[strings.ToLower(m[5])]:
+		case m[5] == "" && move.Promotion != "":
+		default:
+			found = append(found, move)
+		}
+	}
+	if len(found) != 1 {
+		return MoveData{}, errors.New("Illegal or unrecognized move: " + text)
+	}
+	return found[0], nil
+}
+
+// sanWithoutSuffix builds the SAN of a move without the check or mate suffix.
+// legal must hold the legal moves of the position, used for disambiguation.
+func sanWithoutSuffix(board *cache.Board, move MoveData, legal []MoveData) string {
+	if IsCastling(move) {
+		return CastlingNotation(move)
+	}
+	capture := board.Squares[move.To.Row][move.To.Col] != "" || IsEnPassant(board, move)
+	to := SquareName(move.To)
+
+	if isPiece(move.Piece, "pawn") {
+		san := to
+		if capture {
+			san = SquareName(move.From)[:1] + "x" + to
+		}
+		return san + PromotionNotation(move)
+	}
+
+	san := pieceLetter(move.Piece[strings.Index(move.Piece, "-")+1:])
+	// Disambiguate when another piece of the same type can reach the same square
+	sameFile, sameRank, ambiguous := false, false, false
+	for _, other := range legal {
+		if other.Piece != move.Piece || other.To != move.To || other.From == move.From {
+			continue
+		}
+		ambiguous = true
+		if other.From.Col == move.From.Col {
+			sameFile = true
+		}
+		if other.From.Row == move.From.Row {
+			sameRank = true
+		}
+	}
+	from := SquareName(move.From)
+	switch {
+	case !ambiguous:
+	case !sameFile:
+		san += from[:1]
+	case !sameRank:
+		san += from[1:]
+	default:
+		san += from
+	}
+	if capture {
+		san += "x"
+	}
+	return san + to
+}
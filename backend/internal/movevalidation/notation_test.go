@@ -0,0 +1,77 @@
+package movevalidation
+
+import (
+	"testing"
+
+	"gophermatebackend/internal/cache"
+)
+
+func TestSANAndUCI(t *testing.T) {
+	tests := []struct {
+		fen, uci, san string
+	}{
+		{cache.StartingFEN, "g1f3", "Nf3"},
+		{"4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", "b1d2", "Nbd2"},
+		{"4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", "f1d2", "Nfd2"},
+		{"4k3/8/8/R7/8/8/8/R3K3 w - - 0 1", "a1a3", "R1a3"},
+		{"4k3/8/8/R7/8/8/8/R3K3 w - - 0 1", "a5a3", "R5a3"},
+		{"8/8/1k6/8/4Q2Q/8/8/K6Q w - - 0 1", "h4e1", "Qh4e1"},
+		{"8/8/1k6/8/4Q2Q/8/8/K6Q w - - 0 1", "e4e1", "Qee1"},
+		{"8/8/1k6/8/4Q2Q/8/8/K6Q w - - 0 1", "h1e1", "Q1e1"},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", "Ra8+"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2", "d8h4", "Qh4#"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "e5d6", "exd6"},
+		{"1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8q", "axb8=Q+"},
+		{"4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7a8n", "a8=N"},
+	}
+	for _, tc := range tests {
+		board, err := cache.NewBoardFromFEN(tc.fen)
+		if err != nil {
+			t.Fatalf("NewBoardFromFEN(%q): %v", tc.fen, err)
+		}
+		move, err := ParseMove(board, tc.uci)
+		if err != nil {
+			t.Errorf("ParseMove(%q) in %q: %v", tc.uci, tc.fen, err)
+			continue
+		}
+		if got := SAN(board, move); got != tc.san {
+			t.Errorf("SAN of %s in %q = %q, want %q", tc.uci, tc.fen, got, tc.san)
+		}
+		if got := UCI(move); got != tc.uci {
+			t.Errorf("UCI of %s = %q", tc.uci, got)
+		}
+		fromSAN, err := ParseMove(board, tc.san)
+		if err != nil {
+			t.Errorf("ParseMove(%q) in %q: %v", tc.san, tc.fen, err)
+		} else if fromSAN != move {
+			t.Errorf("ParseMove(%q) = %+v, want %+v", tc.san, fromSAN, move)
+		}
+	}
+}
+
+func TestParseMoveLenientSAN(t *testing.T) {
+	board, err := cache.NewBoardFromFEN("4k3/8/8/8/8/5N2/8/1N2K2R w K - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for text, want := range map[string]string{
+		"0-0":   "e1g1",
+		"Nb1d2": "b1d2",
+		"Nfd2!": "f3d2",
+		"Kd2+":  "e1d2",
+	} {
+		move, err := ParseMove(board, text)
+		if err != nil {
+			t.Errorf("ParseMove(%q): %v", text, err)
+		} else if got := UCI(move); got != want {
+			t.Errorf("ParseMove(%q) = %s, want %s", text, got, want)
+		}
+	}
+	for _, text := range []string{"Nd2", "Nc4", "O-O-O", ""} {
+		if _, err := ParseMove(board, text); err == nil {
+			t.Errorf("ParseMove(%q) accepted an ambiguous or illegal move", text)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package movevalidation
+
+import (
+	"testing"
+
+	"gophermatebackend/internal/cache"
+)
+
+// perft counts the leaf nodes of the legal move tree down to the given depth.
+// Expected counts are from https://www.chessprogramming.org/Perft_Results.
+func perft(board *cache.Board, depth int) int {
+	moves := LegalMoves(board)
+	if depth == 1 {
+		return len(moves)
+	}
+	nodes := 0
+	for _, move := range moves {
+		next := *board
+		ApplyMove(&next, move)
+		nodes += perft(&next, depth-1)
+	}
+	return nodes
+}
+
+func TestPerft(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		nodes []int // by depth, starting at 1
+	}{
+		{"initial", cache.StartingFEN, []int{20, 400, 8902}},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []int{48, 2039, 97862}},
+		{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []int{14, 191, 2812}},
+		{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []int{6, 264, 9467}},
+		{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []int{44, 1486, 62379}},
+	}
+	for _, tc := range tests {
+		board, err := cache.NewBoardFromFEN(tc.fen)
+		if err != nil {
+			t.Fatalf("%s: NewBoardFromFEN: %v", tc.name, err)
+		}
+		for i, want := range tc.nodes {
+			if got := perft(board, i+1); got != want {
+				t.Errorf("%s: perft(%d) = %d, want %d", tc.name, i+1, got, want)
+			}
+		}
+	}
+}
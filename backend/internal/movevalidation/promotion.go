@@ -0,0 +1,78 @@
+package movevalidation
+
+import "errors"
+
+// PromotionPieces are the pieces a pawn may promote to.
+var PromotionPieces = []string{"queen", "rook", "bishop", "knight"}
+
+// IsPromotion reports whether the move takes a pawn to the last rank.
+func IsPromotion(move MoveData) bool {
+	if !isPiece(move.Piece, "pawn") {
+		return false
+	}
+	lastRow := 0
+	if getColor(move.Piece) == "black" {
+		lastRow = 7
+	}
+	return move.To.Row == lastRow
+}
+
+// validatePromotion requires a promotion piece exactly when a pawn reaches the last rank.
+func validatePromotion(move MoveData) error {
+	if !IsPromotion(move) {
+		if move.Promotion != "" {
+			return errors.New("Only a pawn reaching the last rank can promote")
+		}
+		return nil
+	}
+	if move.Promotion == "" {
+		return errors.New("Promotion piece is required")
+	}
+	for _, p := range PromotionPieces {
+		if move.Promotion == p {
+			return nil
+		}
+	}
+	return errors.New("Invalid promotion piece")
+}
+
+// placedPiece returns the piece that ends up on the target square, taking promotion into account.
+func placedPiece(move MoveData) string {
+	if move.Promotion != "" {
+		return getColor(move.Piece) + "-" + move.Promotion
+	}
+	return move.Piece
+}
+
+// PromotionNotation returns the suffix recorded for a promotion (e.g., "=Q"), or "" if the move is not one.
+func PromotionNotation(move MoveData) string {
+	if move.Promotion == "" {
+		return ""
+	}
+	return "=" + pieceLetter(move.Promotion)
+}
+
+// pieceLetter returns the upper-case letter used for a piece type in notation ("" for pawns).
+func pieceLetter(pieceType string) string {
+	switch pieceType {
+	case "king":
+		return "K"
+	case "queen":
+		return "Q"
+	case "rook":
+		return "R"
+	case "bishop":
+		return "B"
+	case "knight":
+		return "N"
+	}
+	return ""
+}
+
+// promotionChoices returns the promotion values to try for a candidate move during move generation.
+func promotionChoices(move MoveData) []string {
+	if IsPromotion(move) {
+		return PromotionPieces
+	}
+	return []string{""}
+}
@@ -0,0 +1,42 @@
+package movevalidation
+
+import "testing"
+
+func TestPromotion(t *testing.T) {
+	board := boardWith("black", map[string]string{
+		"e1": "white-king",
+		"b7": "white-pawn",
+		"a8": "black-rook",
+		"h8": "black-king",
+	})
+	for name, promotion := range map[string]string{"missing": "", "invalid": "king"} {
+		push := pieceMove("white-pawn", "b7", "b8")
+		push.Promotion = promotion
+		if ok, _ := ValidateMove(board, push); ok {
+			t.Errorf("promotion with %s piece accepted", name)
+		}
+	}
+
+	capture := pieceMove("white-pawn", "b7", "a8")
+	capture.Promotion = "knight"
+	if ok, err := ValidateMove(board, capture); !ok {
+		t.Fatalf("bxa8=N rejected: %v", err)
+	}
+	ApplyMove(board, capture)
+	if board.Squares[0][0] != "white-knight" || board.Squares[1][1] != "" {
+		t.Errorf("a8 = %q, b7 = %q after bxa8=N", board.Squares[0][0], board.Squares[1][1])
+	}
+}
+
+func TestPromotionOnlyOnLastRank(t *testing.T) {
+	board := boardWith("black", map[string]string{
+		"e1": "white-king",
+		"b6": "white-pawn",
+		"h8": "black-king",
+	})
+	push := pieceMove("white-pawn", "b6", "b7")
+	push.Promotion = "queen"
+	if ok, _ := ValidateMove(board, push); ok {
+		t.Error("promotion before the last rank accepted")
+	}
+}
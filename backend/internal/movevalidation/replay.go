@@ -0,0 +1,60 @@
+package movevalidation
+
+import (
+	"errors"
+
+	"gophermatebackend/internal/cache"
+)
+
+// ReplayMove reads a stored move (see ParseStoredMove), validates it against the board, applies it
+// and returns the move with its SAN.
+func ReplayMove(board *cache.Board, notation string, uci string) (MoveData, string, error) {
+	move, err := checkStoredMove(board, notation, uci)
+	if err != nil {
+		return MoveData{}, "", err
+	}
+	san := SAN(board, move)
+	ApplyMove(board, move)
+	return move, san, nil
+}
+
+// ApplyStoredMove is ReplayMove without the SAN, which needs every legal move of the position.
+// Use it when only the resulting board matters.
+func ApplyStoredMove(board *cache.Board, notation string, uci string) (MoveData, error) {
+	move, err := checkStoredMove(board, notation, uci)
+	if err != nil {
+		return MoveData{}, err
+	}
+	ApplyMove(board, move)
+	return move, nil
+}
+
+// checkStoredMove reads a stored move and validates it against the board.
+func checkStoredMove(board *cache.Board, notation string, uci string) (MoveData, error) {
+	move, err := ParseStoredMove(board, notation, uci)
+	if err != nil {
+		return MoveData{}, err
+	}
+	valid, err := ValidateMove(board, move)
+	if !valid {
+		if !isLegacyNotation(notation, uci) || !legacyMoveAllowed(board, move) {
+			if err == nil {
+				err = errors.New("Invalid move")
+			}
+			return MoveData{}, err
+		}
+		// The old engine checked neither promotions nor checks, so the move is applied as it was then
+	}
+	return move, nil
+}
+
+// legacyMoveAllowed reports whether the engine that recorded the legacy notation accepted the move:
+// it only checked the turn and how the piece moves. A pawn reaching the last rank stayed a pawn and
+// a king could move into check.
+func legacyMoveAllowed(board *cache.Board, move MoveData) bool {
+	if move.Promotion != "" || getColor(move.Piece) != SideToMove(board) {
+		return false
+	}
+	valid, _ := validatePieceMove(board, move)
+	return valid
+}
@@ -0,0 +1,43 @@
+package movevalidation
+
+import (
+	"testing"
+
+	"gophermatebackend/internal/cache"
+)
+
+// Games recorded before check and promotion support hold moves the old engine accepted,
+// which must still replay so those games can be rebuilt.
+func TestReplayMoveLegacyNotation(t *testing.T) {
+	tests := []struct {
+		name, fen, notation, uci string
+		square, want             string // piece expected on square afterwards, "" when the move is rejected
+	}{
+		{"king into check", "4k3/8/8/8/8/8/r7/4K3 w - - 0 1", "white-king e1->e2", "", "e2", "white-king"},
+		{"pawn on the last rank", "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "white-pawn a7->a8", "", "a8", "white-pawn"},
+		{"king into check in UCI", "4k3/8/8/8/8/8/r7/4K3 w - - 0 1", "Ke2", "e1e2", "e2", ""},
+		{"impossible piece move", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", "white-king e1->e3", "", "e3", ""},
+		{"wrong side", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", "black-king e8->e7", "", "e7", ""},
+	}
+	for _, tc := range tests {
+		board, err := cache.NewBoardFromFEN(tc.fen)
+		if err != nil {
+			t.Fatalf("%s: NewBoardFromFEN: %v", tc.name, err)
+		}
+		_, _, err = ReplayMove(board, tc.notation, tc.uci)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("%s: %q was replayed", tc.name, tc.notation)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %q rejected: %v", tc.name, tc.notation, err)
+			continue
+		}
+		pos := sq(tc.square)
+		if got := board.Squares[pos.Row][pos.Col]; got != tc.want {
+			t.Errorf("%s: %s holds %q, want %q", tc.name, tc.square, got, tc.want)
+		}
+	}
+}
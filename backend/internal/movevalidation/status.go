@@ -0,0 +1,58 @@
+package movevalidation
+
+import "gophermatebackend/internal/cache"
+
+const (
+	StatusCheckmate = "checkmate"
+	StatusStalemate = "stalemate"
+)
+
+// SideToMove returns the color that has to play next, based on board.LastMove.
+func SideToMove(board *cache.Board) string {
+	if board.LastMove == "white" {
+		return "black"
+	}
+	return "white"
+}
+
+// LegalMoves returns every legal move for the side to move.
+func LegalMoves(board *cache.Board) []MoveData {
+	color := SideToMove(board)
+	var moves []MoveData
+	for fr := 0; fr < 8; fr++ {
+		for fc := 0; fc < 8; fc++ {
+			piece := board.Squares[fr][fc]
+			if getColor(piece) != color {
+				continue
+			}
+			for tr := 0; tr < 8; tr++ {
+				for tc := 0; tc < 8; tc++ {
+					move := MoveData{
+						Piece: piece,
+						From:  Position{Row: fr, Col: fc},
+						To:    Position{Row: tr, Col: tc},
+					}
+					// Pawn moves to the last rank count once per promotion piece
+					for _, promotion := range promotionChoices(move) {
+						move.Promotion = promotion
+						if ok, _ := ValidateMove(board, move); ok {
+							moves = append(moves, move)
+						}
+					}
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// GameStatus returns StatusCheckmate or StatusStalemate when the side to move has no legal moves, or "" otherwise.
+func GameStatus(board *cache.Board) string {
+	if len(LegalMoves(board)) > 0 {
+		return ""
+	}
+	if IsInCheck(board, SideToMove(board)) {
+		return StatusCheckmate
+	}
+	return StatusStalemate
+}
@@ -0,0 +1,130 @@
+package pgn
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	tagPattern        = regexp.MustCompile(`^\[\s*([A-Za-z0-9_]+)\s+"((?:[^"\\]|\\.)*)"\s*\]$`)
+	moveNumberPattern = regexp.MustCompile(`^\d+(\.+|$)`) // "12.", "12..." or a bare "12", but not "0-0"
+)
+
+// Parse reads one or more games from PGN text. Comments, variations and NAGs are skipped;
+// the move text is returned as written, without move numbers.
+func Parse(text string) ([]Game, error) {
+	var games []Game
+	var current *Game
+	inMoves := false
+
+	finish := func() {
+		if current != nil {
+			if current.Result == "" {
+				current.Result = current.Tag("Result")
+			}
+			if current.Result == "" {
+				current.Result = "*"
+			}
+			games = append(games, *current)
+		}
+		current = nil
+		inMoves = false
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	commentDepth, variationDepth := 0, 0
+	for lineNo, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if commentDepth == 0 && strings.HasPrefix(trimmed, "%") {
+			continue // escape mechanism: the whole line is ignored
+		}
+		if commentDepth == 0 && variationDepth == 0 && strings.HasPrefix(trimmed, "[") {
+			m := tagPattern.FindStringSubmatch(trimmed)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: invalid tag pair", lineNo+1)
+			}
+			// A tag after move text starts the next game
+			if current != nil && inMoves {
+				finish()
+			}
+			if current == nil {
+				current = &Game{}
+			}
+			value := strings.ReplaceAll(strings.ReplaceAll(m[2], `\"`, `"`), `\\`, `\`)
+			current.Tags = append(current.Tags, Tag{Name: m[1], Value: value})
+			continue
+		}
+
+		for _, token := range tokenize(line, &commentDepth, &variationDepth) {
+			if current == nil {
+				current = &Game{}
+			}
+			inMoves = true
+			switch token {
+			case "1-0", "0-1", "1/2-1/2", "*":
+				current.Result = token
+				finish()
+				continue
+			}
+			token = moveNumberPattern.ReplaceAllString(token, "")
+			if token == "" || token == "e.p." {
+				continue
+			}
+			current.Moves = append(current.Moves, token)
+		}
+	}
+	if commentDepth > 0 {
+		return nil, fmt.Errorf("unterminated comment")
+	}
+	if current != nil && (inMoves || len(current.Tags) > 0) {
+		finish()
+	}
+	return games, nil
+}
+
+// tokenize splits a line of move text into tokens, dropping comments ({...} and ;...),
+// variations ((...)) and NAGs ($n). Comment and variation depth carry over between lines.
+func tokenize(line string, commentDepth *int, variationDepth *int) []string {
+	var tokens []string
+	var sb strings.Builder
+	flush := func() {
+		if sb.Len() > 0 {
+			token := sb.String()
+			sb.Reset()
+			if *variationDepth == 0 && !strings.HasPrefix(token, "$") {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	for _, ch := range line {
+		if *commentDepth > 0 {
+			if ch == '}' {
+				*commentDepth = 0
+			}
+			continue
+		}
+		switch {
+		case ch == '{':
+			flush()
+			*commentDepth = 1
+		case ch == ';':
+			flush()
+			return tokens
+		case ch == '(':
+			flush()
+			*variationDepth++
+		case ch == ')':
+			flush()
+			if *variationDepth > 0 {
+				*variationDepth--
+			}
+		case ch == ' ' || ch == '\t':
+			flush()
+		default:
+			sb.WriteRune(ch)
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,122 @@
+package pgn
+
+import (
+	"strconv"
+	"strings"
+)
+
+const maxLineLength = 80
+
+// Tag is a PGN tag pair such as [White "alice"].
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Game is a single game in PGN form: its tag pairs, SAN moves and result token.
+type Game struct {
+	Tags   []Tag
+	Moves  []string // SAN moves in order
+	Result string   // "1-0", "0-1", "1/2-1/2" or "*"
+}
+
+// Tag returns the value of the named tag, or "" if it is not present.
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// ResultFromWinner converts a games.winner value ("white", "black", "draw" or "") to a PGN result token.
+func ResultFromWinner(winner string) string {
+	switch winner {
+	case "white":
+		return "1-0"
+	case "black":
+		return "0-1"
+	case "draw":
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+// WinnerFromResult converts a PGN result token to a games.winner value, or "" for an unfinished game.
+func WinnerFromResult(result string) string {
+	switch result {
+	case "1-0":
+		return "white"
+	case "0-1":
+		return "black"
+	case "1/2-1/2":
+		return "draw"
+	}
+	return ""
+}
+
+// Format renders the game as PGN text. Move numbers start from the FEN tag when the game
+// does not begin at the standard starting position.
+func Format(g Game) string {
+	var sb strings.Builder
+	for _, t := range g.Tags {
+		sb.WriteString("[" + t.Name + " \"" + escapeTagValue(t.Value) + "\"]\n")
+	}
+	sb.WriteString("\n")
+
+	moveNumber, blackToMove := startingMove(g.Tag("FEN"))
+	var tokens []string
+	for i, san := range g.Moves {
+		if !blackToMove {
+			tokens = append(tokens, strconv.Itoa(moveNumber)+".")
+		} else if i == 0 {
+			tokens = append(tokens, strconv.Itoa(moveNumber)+"...")
+		}
+		tokens = append(tokens, san)
+		if blackToMove {
+			moveNumber++
+		}
+		blackToMove = !blackToMove
+	}
+	result := g.Result
+	if result == "" {
+		result = "*"
+	}
+	tokens = append(tokens, result)
+
+	lineLength := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if lineLength+1+len(token) > maxLineLength {
+				sb.WriteString("\n")
+				lineLength = 0
+			} else {
+				sb.WriteString(" ")
+				lineLength++
+			}
+		}
+		sb.WriteString(token)
+		lineLength += len(token)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// startingMove reads the fullmove number and side to move from a FEN, defaulting to move 1 with white.
+func startingMove(fen string) (int, bool) {
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		return 1, false
+	}
+	number, err := strconv.Atoi(fields[5])
+	if err != nil || number < 1 {
+		number = 1
+	}
+	return number, fields[1] == "b"
+}
+
+func escapeTagValue(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	return strings.ReplaceAll(value, "\"", "\\\"")
+}
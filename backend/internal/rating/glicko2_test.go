@@ -0,0 +1,45 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+// TestUpdatePeriodGlickmanExample follows the worked example of the Glicko-2 paper, which gives
+// 1464.06, 151.52 and 0.05999 (the volatility is 0.059996 before truncation).
+func TestUpdatePeriodGlickmanExample(t *testing.T) {
+	player := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	results := []Result{
+		{Opponent: Rating{Rating: 1400, Deviation: 30, Volatility: 0.06}, Score: 1},
+		{Opponent: Rating{Rating: 1550, Deviation: 100, Volatility: 0.06}, Score: 0},
+		{Opponent: Rating{Rating: 1700, Deviation: 300, Volatility: 0.06}, Score: 0},
+	}
+	got := updatePeriod(player, results)
+	if math.Abs(got.Rating-1464.06) > 0.05 {
+		t.Errorf("rating = %.2f, want 1464.06", got.Rating)
+	}
+	if math.Abs(got.Deviation-151.52) > 0.05 {
+		t.Errorf("deviation = %.2f, want 151.52", got.Deviation)
+	}
+	if math.Abs(got.Volatility-0.059996) > 0.000001 {
+		t.Errorf("volatility = %.7f, want 0.059996", got.Volatility)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	player, opponent := Default(), Default()
+	won := Update(player, opponent, 1)
+	lost := Update(opponent, player, 0)
+	if won.Rating <= DefaultRating || lost.Rating >= DefaultRating {
+		t.Errorf("winner %.2f and loser %.2f did not move apart from %v", won.Rating, lost.Rating, DefaultRating)
+	}
+	if math.Abs((won.Rating-DefaultRating)+(lost.Rating-DefaultRating)) > 1e-9 {
+		t.Errorf("equal players changed by %.4f and %.4f", won.Rating-DefaultRating, lost.Rating-DefaultRating)
+	}
+	if won.Deviation >= DefaultDeviation {
+		t.Errorf("deviation = %.2f after a game, want below %v", won.Deviation, DefaultDeviation)
+	}
+	if drawn := Update(player, opponent, 0.5); math.Abs(drawn.Rating-DefaultRating) > 1e-9 {
+		t.Errorf("draw between equal players changed the rating to %.4f", drawn.Rating)
+	}
+}
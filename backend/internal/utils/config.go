@@ -2,6 +2,8 @@ package utils
 
 import (
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +15,14 @@ type Config struct {
 	DBHost     string
 	DBPort     string
 	Port       string
+	// ChallengeExpiry is how long a direct challenge waits for an answer
+	ChallengeExpiry time.Duration
+	// AccessTokenTTL is how long a session token stays valid without being used
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a refresh token can be exchanged for new tokens
+	RefreshTokenTTL time.Duration
+	// AllowedOrigins are the frontend origins allowed to make credentialed requests and open WebSockets
+	AllowedOrigins []string
 }
 
 func LoadConfig() *Config {
@@ -28,6 +38,11 @@ func LoadConfig() *Config {
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		Port:       getEnv("PORT", "8080"),
+
+		ChallengeExpiry: getEnvDuration("CHALLENGE_EXPIRY", 24*time.Hour),
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", time.Hour),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		AllowedOrigins:  getEnvList("ALLOWED_ORIGINS", "http://localhost:5173"),
 	}
 }
 
@@ -37,3 +52,23 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvDuration reads a duration such as "24h" or "30m", using the fallback if it is missing or invalid.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// getEnvList reads a comma-separated list such as "https://a.example,https://b.example".
+func getEnvList(key, fallback string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, fallback), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
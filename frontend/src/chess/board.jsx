@@ -29,6 +29,29 @@ export function InitializeBoard() {
     return initialBoard;
 };
 
+const FEN_PIECES = {
+    p: 'pawn', n: 'knight', b: 'bishop', r: 'rook', q: 'queen', k: 'king',
+};
+
+// Builds a board from the piece placement field of a FEN string, e.g. "rnbqkbnr/pppppppp/8/..."
+export function BoardFromFEN(fen) {
+    const board = Array(8).fill(null).map(() => Array(8).fill(null));
+    const ranks = fen.split(' ')[0].split('/');
+    ranks.forEach((rank, row) => {
+        let col = 0;
+        for (const ch of rank) {
+            if (ch >= '1' && ch <= '8') {
+                col += parseInt(ch, 10);
+                continue;
+            }
+            const color = ch === ch.toUpperCase() ? 'white' : 'black';
+            board[row][col] = `${color}-${FEN_PIECES[ch.toLowerCase()]}`;
+            col++;
+        }
+    });
+    return board;
+}
+
 const Board = ({ boardState, onMove }) => {
     // Timing and state refs
     const mouseDownInfo = useRef({ time: 0, row: null, col: null, triggered: false });
@@ -1,11 +1,11 @@
 import { useState, useEffect, useRef } from 'react';
 import React from 'react';
 import { useParams } from 'react-router-dom';
-import Board, { InitializeBoard } from '../chess/board';
+import Board, { InitializeBoard, BoardFromFEN } from '../chess/board';
 import MoveLog from './MoveLog';
 import { postMove as postMoveApi } from '../services/gameService';
 import './GameSessionPage.css';
-import { API_URL } from '../services/authService';
+import { authFetch } from '../services/authService';
 
 
 // Simple modal for draw offer
@@ -28,7 +28,6 @@ const GameSessionPage = () => {
     const [boardState, setBoardState] = useState(InitializeBoard());
     const [selected, setSelected] = useState(null); // For click-based selection
     const dragStart = useRef(null); // For mousedown/mouseup drag
-    const userToken = localStorage.getItem('token'); // Assuming user token is stored in localStorage
     const [lastMoveNumber, setLastMoveNumber] = useState(0); // Track last move number
     const [lastMoveNotation, setLastMoveNotation] = useState(''); // Track last move notation
     const [turn, setTurn] = useState('white'); // Track whose turn it is
@@ -39,27 +38,11 @@ const GameSessionPage = () => {
     const [playerWhite, setPlayerWhite] = useState(null);
     const [playerBlack, setPlayerBlack] = useState(null);
 
-    // Helper to parse notation like "white-pawn e2->e4" and update boardState
-    function applyNotationToBoard(notation) {
-        if (!notation) return;
-        // Example: "white-pawn e2->e4"
-        const match = notation.match(/^(\w+-\w+)\s+([a-h][1-8])->([a-h][1-8])$/);
-        if (!match) return;
-        const [, piece, from, to] = match;
-        // Convert algebraic to board indices
-        function algebraicToIndex(square) {
-            const col = square.charCodeAt(0) - 'a'.charCodeAt(0);
-            const row = 7 - (parseInt(square[1], 10) - 1);
-            return { row, col };
-        }
-        const fromIdx = algebraicToIndex(from);
-        const toIdx = algebraicToIndex(to);
-        setBoardState(prev => {
-            const newBoard = prev.map(r => r.slice());
-            newBoard[toIdx.row][toIdx.col] = newBoard[fromIdx.row][fromIdx.col];
-            newBoard[fromIdx.row][fromIdx.col] = null;
-            return newBoard;
-        });
+    // Helper to render the position and turn from a FEN string returned by the server
+    function applyFENToBoard(fen) {
+        if (!fen) return;
+        setBoardState(BoardFromFEN(fen));
+        setTurn(fen.split(' ')[1] === 'b' ? 'black' : 'white');
     }
 
     useEffect(() => {
@@ -67,21 +50,15 @@ const GameSessionPage = () => {
         let intervalId = null;
         async function fetchBoard() {
             try {
-                const res = await fetch(`${API_URL}/api/games/${id}/board`, {
-                    headers: {
-                        'Authorization': userToken ? `Bearer ${userToken}` : undefined,
-                    },
-                });
+                const res = await authFetch(`/api/games/${id}/board`);
                 if (!res.ok) return;
                 const data = await res.json();
                 if (isMounted) {
-                    // format is { number: 1, notation: "white-pawn e2->e4", draw_offer: "white" }
+                    // format is { number: 1, notation: "e4", fen: "rnbqkbnr/...", draw_offer: "white" }
                     if (lastMoveNumber !== data.number) {
-                        const turn = data.notation.split(' ')[0].split('-')[0]; // e.g., "white" from "white-pawn e2->e4"
-                        setTurn(turn === 'white' ? 'black' : 'white');
                         setLastMoveNumber(data.number);
                         setLastMoveNotation(data.notation);
-                        applyNotationToBoard(data.notation);
+                        applyFENToBoard(data.fen);
                         setMoveLog(prev => {
                             const newLog = [...prev, data.notation];
                             // Limit the log size for protection
@@ -108,7 +85,7 @@ const GameSessionPage = () => {
             isMounted = false;
             if (intervalId) clearInterval(intervalId);
         };
-    }, [id, userToken, lastMoveNumber]);
+    }, [id, lastMoveNumber]);
 
     // Poll for player info (every 2s)
     useEffect(() => {
@@ -117,19 +94,12 @@ const GameSessionPage = () => {
         let intervalId = null;
         async function fetchPlayers() {
             try {
-                const res = await fetch(`${API_URL}/api/games`);
+                const res = await authFetch(`/api/games/${id}/state`);
                 if (!res.ok) return;
-                const data = await res.json();
-                const game = data.find(g => g.id === id);
-                if (game) {
-                    setPlayerWhite(game.player_white);
-                    setPlayerBlack(game.player_black);
-                    if (game.player_white && game.player_black) {
-                        setWaitingForOpponent(false);
-                    } else {
-                        setWaitingForOpponent(true);
-                    }
-                }
+                const game = await res.json();
+                setPlayerWhite(game.white || null);
+                setPlayerBlack(game.black || null);
+                setWaitingForOpponent(game.status === 'waiting');
             } catch (e) {
                 // ignore
             }
@@ -144,15 +114,26 @@ const GameSessionPage = () => {
 
 
     async function postMove(piece, from, to) {
+        let promotion;
+        if (piece.endsWith('-pawn') && (to.row === 0 || to.row === 7)) {
+            promotion = window.prompt('Promote to (queen, rook, bishop, knight)', 'queen');
+            if (!promotion) return false;
+        }
         try {
             const data = await postMoveApi({
                 session: id,
-                user: userToken,
                 piece,
                 from,
                 to,
+                promotion,
             });
             setLastMoveNumber(lastMoveNumber + 1);
+            applyFENToBoard(data.fen);
+            setMoveLog(prev => {
+                const newLog = [...prev, data.notation];
+                const MESSAGE_LIMIT = 50;
+                return newLog.slice(-MESSAGE_LIMIT);
+            });
             return true;
         } catch (error) {
             alert('Invalid move: ' + error.error);
@@ -162,13 +143,7 @@ const GameSessionPage = () => {
 
     async function resignGame() {
         try {
-            const res = await fetch(`${API_URL}/api/games/${id}/resign`, {
-                method: 'POST',
-                headers: {
-                    'Content-Type': 'application/json',
-                },
-                body: JSON.stringify({ player_token: userToken }),
-            });
+            const res = await authFetch(`/api/games/${id}/resign`, { method: 'POST' });
             const data = await res.json();
             if (res.ok) {
                 alert(`You resigned. Winner: ${data.winner}`);
@@ -183,13 +158,7 @@ const GameSessionPage = () => {
 
     async function offerDraw() {
         try {
-            const res = await fetch(`${API_URL}/api/games/${id}/offer-draw`, {
-                method: 'POST',
-                headers: {
-                    'Content-Type': 'application/json',
-                },
-                body: JSON.stringify({ player_token: userToken }),
-            });
+            const res = await authFetch(`/api/games/${id}/offer-draw`, { method: 'POST' });
             const data = await res.json();
             if (res.ok) {
                 alert('Draw offer sent.');
@@ -203,13 +172,7 @@ const GameSessionPage = () => {
 
     async function acceptDraw() {
         try {
-            const res = await fetch(`${API_URL}/api/games/${id}/accept-draw`, {
-                method: 'POST',
-                headers: {
-                    'Content-Type': 'application/json',
-                },
-                body: JSON.stringify({ player_token: userToken }),
-            });
+            const res = await authFetch(`/api/games/${id}/accept-draw`, { method: 'POST' });
             const data = await res.json();
             if (res.ok) {
                 alert('Draw accepted! Game ends in a draw.');
@@ -226,13 +189,7 @@ const GameSessionPage = () => {
 
     async function declineDraw() {
         try {
-            const res = await fetch(`${API_URL}/api/games/${id}/decline-draw`, {
-                method: 'POST',
-                headers: {
-                    'Content-Type': 'application/json',
-                },
-                body: JSON.stringify({ player_token: userToken }),
-            });
+            const res = await authFetch(`/api/games/${id}/decline-draw`, { method: 'POST' });
             const data = await res.json();
             if (res.ok) {
                 alert('Draw declined.');
@@ -262,25 +219,8 @@ const GameSessionPage = () => {
                 const to = { row, col };
                 if (from.row !== to.row || from.col !== to.col) {
                     // Post the move to the server
-                    const moveOk = await postMove(boardState[from.row][from.col], from, to);
-                    if (moveOk) {
-                        const newBoard = boardState.map(r => r.slice());
-                        newBoard[to.row][to.col] = newBoard[from.row][from.col];
-                        newBoard[from.row][from.col] = null;
-                        setBoardState(newBoard);
-                        // Construct move notation and update moveLog immediately
-                        const piece = boardState[from.row][from.col];
-                        const fromSquare = String.fromCharCode('a'.charCodeAt(0) + from.col) + (8 - from.row);
-                        const toSquare = String.fromCharCode('a'.charCodeAt(0) + to.col) + (8 - to.row);
-                        const notation = `${piece} ${fromSquare}->${toSquare}`;
-                        setMoveLog(prev => {
-                            const newLog = [...prev, notation];
-                            const MESSAGE_LIMIT = 50;
-                            return newLog.slice(-MESSAGE_LIMIT);
-                        });
-                        // Toggle turn after local move
-                        setTurn(prevTurn => (prevTurn === 'white' ? 'black' : 'white'));
-                    }
+                    // Board, turn and move log are updated from the server response
+                    await postMove(boardState[from.row][from.col], from, to);
                 }
                 setSelected(null);
             }
@@ -295,25 +235,8 @@ const GameSessionPage = () => {
             // End drag and move
             const from = dragStart.current;
             if (from && (from.row !== row || from.col !== col)) {
-                const moveOk = await postMove(boardState[from.row][from.col], from, { row, col });
-                if (moveOk) {
-                    const newBoard = boardState.map(r => r.slice());
-                    newBoard[row][col] = newBoard[from.row][from.col];
-                    newBoard[from.row][from.col] = null;
-                    setBoardState(newBoard);
-                    // Construct move notation and update moveLog immediately
-                    const piece = boardState[from.row][from.col];
-                    const fromSquare = String.fromCharCode('a'.charCodeAt(0) + from.col) + (8 - from.row);
-                    const toSquare = String.fromCharCode('a'.charCodeAt(0) + col) + (8 - row);
-                    const notation = `${piece} ${fromSquare}->${toSquare}`;
-                    setMoveLog(prev => {
-                        const newLog = [...prev, notation];
-                        const MESSAGE_LIMIT = 50;
-                        return newLog.slice(-MESSAGE_LIMIT);
-                    });
-                    // Toggle turn after local move
-                    setTurn(prevTurn => (prevTurn === 'white' ? 'black' : 'white'));
-                }
+                // Board, turn and move log are updated from the server response
+                await postMove(boardState[from.row][from.col], from, { row, col });
             }
             dragStart.current = null;
         }
@@ -1,31 +1,52 @@
 import React, { useEffect, useState } from 'react';
-import { API_URL } from '../services/authService';
+import { authFetch, logoutUser } from '../services/authService';
+
+// Username with the rating in the game's pool; a ? marks a provisional rating
+const playerLabel = (player) => {
+  if (!player) return '-';
+  if (player.rating === null) return player.username;
+  return `${player.username} (${player.rating}${player.provisional ? '?' : ''})`;
+};
 
 const GamesPage = () => {
   const [games, setGames] = useState([]);
   const [joinId, setJoinId] = useState('');
+  const [searching, setSearching] = useState(false);
+  const [color, setColor] = useState('white');
+  const [isPrivate, setIsPrivate] = useState(false);
+  const [timeControl, setTimeControl] = useState('');
+  const [rated, setRated] = useState(false);
+  const [inviteCode, setInviteCode] = useState('');
 
-  useEffect(() => {
-    fetch(`${API_URL}/api/games`)
+  const [filters, setFilters] = useState({ waiting: false, mine: false, time_control: '', sort: 'newest' });
+  const [nextCursor, setNextCursor] = useState(null);
+
+  // Fetch a page of the lobby; without a cursor the list starts over
+  const fetchGames = (cursor) => {
+    const params = new URLSearchParams({ sort: filters.sort });
+    if (filters.waiting) params.set('waiting', 'true');
+    if (filters.mine) params.set('mine', 'true');
+    if (filters.time_control.trim()) params.set('time_control', filters.time_control.trim());
+    if (cursor) params.set('cursor', cursor);
+    authFetch(`/api/games?${params}`)
       .then((response) => response.json())
       .then((data) => {
-        const gamesWithStatus = data.map((game) => ({
-          ...game,
-          status: game.player_black ? 'In Progress' : 'Open',
-        }));
-        setGames(gamesWithStatus);
-        console.log('Fetched games:', gamesWithStatus);
+        if (data.error) {
+          alert(data.error);
+          return;
+        }
+        setGames((previous) => (cursor ? [...previous, ...data.games] : data.games));
+        setNextCursor(data.next_cursor);
       });
-  }, []);
+  };
+
+  useEffect(() => {
+    fetchGames(null);
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [filters]);
 
   const joinGame = (id) => {
-    fetch(`${API_URL}/api/games/${id}/join`, {
-      method: 'POST',
-      headers: {
-        'Content-Type': 'application/json',
-      },
-      body: JSON.stringify({ player_token: localStorage.getItem('token') || ''}),
-    })
+    authFetch(`/api/games/${id}/join`, { method: 'POST' })
       .then((response) => response.json())
       .then((data) => {
         if (data.message) {
@@ -38,31 +59,120 @@ const GamesPage = () => {
   };
 
   const createGame = () => {
-    fetch(`${API_URL}/api/games`, {
+    authFetch('/api/games', {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
       },
-      body: JSON.stringify({ player_token: localStorage.getItem('token') || ''}),
+      body: JSON.stringify({
+        color,
+        private: isPrivate,
+        time_control: timeControl.trim(),
+        rated,
+      }),
     })
       .then((response) => response.json())
       .then((data) => {
         if (data.id) {
           console.log('Create game response:', data);
+          if (data.invite_code) {
+            window.prompt('Private game created. Share this invite code with your opponent:', data.invite_code);
+          }
           // Redirect to the new game session page
           window.location.href = `/gamesession/${data.id}`;
         } else {
-          alert(data.message || 'Failed to create game');
+          alert(data.error || data.message || 'Failed to create game');
         }
       })
       .catch(() => alert('Failed to create game'));
   };
 
+  const joinByInvite = (code) => {
+    authFetch(`/api/invites/${encodeURIComponent(code.trim())}/join`, { method: 'POST' })
+      .then((response) => response.json())
+      .then((data) => {
+        if (data.id) {
+          window.location.href = `/gamesession/${data.id}`;
+        } else {
+          alert(data.error || 'Failed to join game');
+        }
+      });
+  };
+
+  // Quick match: queue for an opponent and keep waiting until the server pairs us
+  const quickMatch = async () => {
+    setSearching(true);
+    try {
+      let response = await authFetch('/api/matchmaking', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({}),
+      });
+      let data = await response.json();
+      while (data.status === 'queued') {
+        response = await authFetch('/api/matchmaking?wait=30s');
+        data = await response.json();
+      }
+      if (data.status === 'matched') {
+        window.location.href = `/gamesession/${data.game_id}`;
+        return;
+      }
+      if (data.status === 'timeout') {
+        alert('No opponent found, try again later');
+      } else if (data.error) {
+        alert(data.error);
+      }
+    } catch (e) {
+      alert('Failed to find a match');
+    }
+    setSearching(false);
+  };
+
+  const logout = async () => {
+    try {
+      await logoutUser(localStorage.getItem('token') || '');
+    } catch (e) {
+      // The session is dropped locally either way
+    }
+    localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
+    window.location.href = '/login';
+  };
+
+  const cancelQuickMatch = () => {
+    authFetch('/api/matchmaking', { method: 'DELETE' });
+  };
+
   return (
     <div className="games-page">
       <div className="header">
+        <select value={color} onChange={e => setColor(e.target.value)} style={{ marginRight: '8px' }}>
+          <option value="white">Play as white</option>
+          <option value="black">Play as black</option>
+          <option value="random">Random color</option>
+        </select>
+        <label style={{ marginRight: '8px' }}>
+          <input type="checkbox" checked={isPrivate} onChange={e => setIsPrivate(e.target.checked)} /> Private
+        </label>
+        <input
+          type="text"
+          placeholder="Time control (e.g. 5+3)"
+          value={timeControl}
+          onChange={e => setTimeControl(e.target.value)}
+          style={{ marginRight: '8px' }}
+        />
+        <label style={{ marginRight: '8px' }}>
+          <input type="checkbox" checked={rated} onChange={e => setRated(e.target.checked)} /> Rated
+        </label>
         <button onClick={createGame}>Create Game</button>
-        <button onClick={() => window.location.href = '/logout'}>Logout</button>
+        {searching ? (
+          <button onClick={cancelQuickMatch}>Searching... (cancel)</button>
+        ) : (
+          <button onClick={quickMatch}>Quick Match</button>
+        )}
+        <button onClick={logout}>Logout</button>
       </div>
       <div className="join-by-id" style={{ margin: '16px 0' }}>
         <input
@@ -75,14 +185,45 @@ const GamesPage = () => {
         <button onClick={() => joinGame(joinId)} disabled={!joinId.trim()}>
           Join by ID
         </button>
+        <input
+          type="text"
+          placeholder="Enter invite code"
+          value={inviteCode}
+          onChange={e => setInviteCode(e.target.value)}
+          style={{ margin: '0 8px 0 16px' }}
+        />
+        <button onClick={() => joinByInvite(inviteCode)} disabled={!inviteCode.trim()}>
+          Join by invite
+        </button>
       </div>
       <div className="games-list">
         <h1>Available Games</h1>
+        <div className="lobby-filters">
+          <label style={{ marginRight: '8px' }}>
+            <input type="checkbox" checked={filters.waiting} onChange={e => setFilters({ ...filters, waiting: e.target.checked })} /> Waiting only
+          </label>
+          <label style={{ marginRight: '8px' }}>
+            <input type="checkbox" checked={filters.mine} onChange={e => setFilters({ ...filters, mine: e.target.checked })} /> My games
+          </label>
+          <input
+            type="text"
+            placeholder="Time control (5+3, 3d, untimed)"
+            defaultValue={filters.time_control}
+            onBlur={e => setFilters({ ...filters, time_control: e.target.value })}
+            style={{ marginRight: '8px' }}
+          />
+          <select value={filters.sort} onChange={e => setFilters({ ...filters, sort: e.target.value })}>
+            <option value="newest">Newest first</option>
+            <option value="oldest">Oldest first</option>
+            <option value="rating">Highest rated</option>
+          </select>
+        </div>
         <table style={{ width: '100%', borderCollapse: 'collapse', marginTop: '16px' }}>
           <thead>
             <tr>
-              <th style={{ border: '1px solid #ccc', padding: '8px' }}>Game ID</th>
+              <th style={{ border: '1px solid #ccc', padding: '8px' }}>Created</th>
               <th style={{ border: '1px solid #ccc', padding: '8px' }}>Status</th>
+              <th style={{ border: '1px solid #ccc', padding: '8px' }}>Time Control</th>
               <th style={{ border: '1px solid #ccc', padding: '8px' }}>Player White</th>
               <th style={{ border: '1px solid #ccc', padding: '8px' }}>Player Black</th>
               <th style={{ border: '1px solid #ccc', padding: '8px' }}>Action</th>
@@ -91,19 +232,28 @@ const GamesPage = () => {
           <tbody>
             {games.map((game) => (
               <tr key={game.id}>
-                <td style={{ border: '1px solid #ccc', padding: '8px', wordBreak: 'break-all' }}>{game.id}</td>
-                <td style={{ border: '1px solid #ccc', padding: '8px' }}>{game.status}</td>
-                <td style={{ border: '1px solid #ccc', padding: '8px' }}>{game.player_white}</td>
-                <td style={{ border: '1px solid #ccc', padding: '8px' }}>{game.player_black || '-'}</td>
+                <td style={{ border: '1px solid #ccc', padding: '8px' }}>{new Date(game.created_at).toLocaleString()}</td>
+                <td style={{ border: '1px solid #ccc', padding: '8px' }}>{game.status === 'waiting' ? 'Open' : 'In Progress'}</td>
                 <td style={{ border: '1px solid #ccc', padding: '8px' }}>
-                  {game.status === 'Open' && (
+                  {game.time_control || 'Untimed'} {game.rated ? 'Rated' : 'Casual'}
+                </td>
+                <td style={{ border: '1px solid #ccc', padding: '8px' }}>{playerLabel(game.white)}</td>
+                <td style={{ border: '1px solid #ccc', padding: '8px' }}>{playerLabel(game.black)}</td>
+                <td style={{ border: '1px solid #ccc', padding: '8px' }}>
+                  {game.status === 'waiting' && !filters.mine && (
                     <button onClick={() => joinGame(game.id)}>Join</button>
                   )}
+                  {filters.mine && (
+                    <button onClick={() => window.location.href = `/gamesession/${game.id}`}>Open</button>
+                  )}
                 </td>
               </tr>
             ))}
           </tbody>
         </table>
+        {nextCursor && (
+          <button onClick={() => fetchGames(nextCursor)} style={{ marginTop: '8px' }}>Load more</button>
+        )}
       </div>
     </div>
   );
@@ -15,6 +15,7 @@ const LoginPage = () => {
       const response = await loginUser({ username, password });
       alert('Success: ' + response.message);
       localStorage.setItem('token', response.token);
+      localStorage.setItem('refresh_token', response.refresh_token);
       window.location.href = '/games';
     } catch (err) {
       setError(err.message || 'Login failed');
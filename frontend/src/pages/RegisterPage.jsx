@@ -21,6 +21,7 @@ const RegisterPage = () => {
       setSuccess(response.message);
       if (response.token) {
         localStorage.setItem('token', response.token);
+        localStorage.setItem('refresh_token', response.refresh_token);
       }
       setTimeout(() => {
         window.location.href = '/games';
@@ -18,4 +18,56 @@ export const loginUser = async (credentials) => {
   } catch (error) {
     throw error.response ? error.response.data : new Error('Network error');
   }
-};
\ No newline at end of file
+};
+
+// Exchanges the stored refresh token for new tokens once the session token has expired.
+export const refreshSession = async () => {
+  try {
+    const response = await axios.post(API_URL + '/api/refresh', {
+      refresh_token: localStorage.getItem('refresh_token') || '',
+    });
+    localStorage.setItem('token', response.data.token);
+    localStorage.setItem('refresh_token', response.data.refresh_token);
+    return response.data;
+  } catch (error) {
+    throw error.response ? error.response.data : new Error('Network error');
+  }
+};
+
+let pendingRefresh = null;
+
+// Requests an API path with the stored session token as a Bearer header. When the session token
+// has expired it refreshes the session once and retries, so callers never handle token expiry.
+export const authFetch = async (path, options = {}) => {
+  const send = (token) => fetch(API_URL + path, {
+    ...options,
+    headers: { ...options.headers, Authorization: `Bearer ${token}` },
+  });
+  const token = localStorage.getItem('token') || '';
+  const response = await send(token);
+  if (response.status !== 401) return response;
+
+  // Another request may have refreshed the session meanwhile
+  if (localStorage.getItem('token') !== token) return send(localStorage.getItem('token') || '');
+  const data = await response.clone().json().catch(() => ({}));
+  if (data.code !== 'token_expired') return response;
+  try {
+    // Share one refresh between concurrent requests: a refresh token only works once
+    pendingRefresh = pendingRefresh || refreshSession().finally(() => { pendingRefresh = null; });
+    await pendingRefresh;
+  } catch (error) {
+    return response;
+  }
+  return send(localStorage.getItem('token') || '');
+};
+
+export const logoutUser = async (token) => {
+  try {
+    const response = await axios.post(API_URL + '/api/logout', {}, {
+      headers: { Authorization: `Bearer ${token}` },
+    });
+    return response.data;
+  } catch (error) {
+    throw error.response ? error.response.data : new Error('Network error');
+  }
+};
@@ -1,18 +1,21 @@
-import axios from 'axios';
-import { API_URL } from './authService';
+import { authFetch } from './authService';
 
 
-export const postMove = async ({ session, user, piece, from, to }) => {
-  try {
-    const response = await axios.post(`${API_URL}/api/games/move`, {
+export const postMove = async ({ session, piece, from, to, promotion }) => {
+  const response = await authFetch('/api/games/move', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({
       session,
-      user,
       piece,
       from,
       to,
-    });
-    return response.data;
-  } catch (error) {
-    throw error.response ? error.response.data : new Error('Network error');
+      promotion,
+    }),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    throw data;
   }
+  return data;
 };